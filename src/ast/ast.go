@@ -2,6 +2,8 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
+	"strconv"
 	"strings"
 
 	"github.com/batt0s/rizzy/token"
@@ -10,6 +12,10 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	// Pos returns the position of the first character of the node,
+	// End the position right after its last character.
+	Pos() token.Position
+	End() token.Position
 }
 
 type Statement interface {
@@ -34,6 +40,19 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{Line: 1, Column: 1}
+}
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{Line: 1, Column: 1}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 	for _, s := range p.Statements {
@@ -52,6 +71,16 @@ func (ls *DefStatement) statementNode() {}
 func (ls *DefStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
+func (ls *DefStatement) Pos() token.Position { return ls.Token.Pos }
+func (ls *DefStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+	if ls.Name != nil {
+		return ls.Name.End()
+	}
+	return ls.Token.End
+}
 func (ls *DefStatement) String() string {
 	var out bytes.Buffer
 
@@ -68,6 +97,74 @@ func (ls *DefStatement) String() string {
 	return out.String()
 }
 
+// StructStatement declares a struct type, struct Point { x, y }.
+type StructStatement struct {
+	Token  token.Token // the 'struct' token
+	Name   *Identifier
+	Fields []*Identifier
+	Rbrace token.Token
+}
+
+func (ss *StructStatement) statementNode()       {}
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StructStatement) Pos() token.Position  { return ss.Token.Pos }
+func (ss *StructStatement) End() token.Position {
+	if ss.Rbrace.End.IsValid() {
+		return ss.Rbrace.End
+	}
+	return ss.Token.End
+}
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+
+	fields := []string{}
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+
+	out.WriteString("struct ")
+	out.WriteString(ss.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// ImplStatement adds methods to a struct type:
+//
+//	impl Point { def norm = func(self) { ... }; }
+type ImplStatement struct {
+	Token   token.Token // the 'impl' token
+	Name    *Identifier
+	Methods []*DefStatement
+	Rbrace  token.Token
+}
+
+func (is *ImplStatement) statementNode()       {}
+func (is *ImplStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImplStatement) Pos() token.Position  { return is.Token.Pos }
+func (is *ImplStatement) End() token.Position {
+	if is.Rbrace.End.IsValid() {
+		return is.Rbrace.End
+	}
+	return is.Token.End
+}
+func (is *ImplStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("impl ")
+	out.WriteString(is.Name.String())
+	out.WriteString(" { ")
+	for _, m := range is.Methods {
+		out.WriteString(m.String())
+		out.WriteString(" ")
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
 type Identifier struct {
 	Token token.Token
 	Value string
@@ -77,6 +174,8 @@ func (i *Identifier) expressionNode() {}
 func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
+func (i *Identifier) Pos() token.Position { return i.Token.Pos }
+func (i *Identifier) End() token.Position { return i.Token.End }
 func (i *Identifier) String() string {
 	return i.Value
 }
@@ -90,6 +189,13 @@ func (rs *ReturnStatement) statementNode() {}
 func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
+func (rs *ReturnStatement) Pos() token.Position { return rs.Token.Pos }
+func (rs *ReturnStatement) End() token.Position {
+	if rs.ReturnValue != nil {
+		return rs.ReturnValue.End()
+	}
+	return rs.Token.End
+}
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 
@@ -113,6 +219,18 @@ func (es *ExpressionStatement) statementNode() {}
 func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
+func (es *ExpressionStatement) Pos() token.Position {
+	if es.Expression != nil {
+		return es.Expression.Pos()
+	}
+	return es.Token.Pos
+}
+func (es *ExpressionStatement) End() token.Position {
+	if es.Expression != nil {
+		return es.Expression.End()
+	}
+	return es.Token.End
+}
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -129,10 +247,29 @@ func (il *IntegerLiteral) expressionNode() {}
 func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
+func (il *IntegerLiteral) Pos() token.Position { return il.Token.Pos }
+func (il *IntegerLiteral) End() token.Position { return il.Token.End }
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+// BigIntLiteral is an integer literal with an "n" suffix or one too
+// large for INTEGER.
+type BigIntLiteral struct {
+	Token token.Token
+	Value *big.Int
+}
+
+func (bl *BigIntLiteral) expressionNode() {}
+func (bl *BigIntLiteral) TokenLiteral() string {
+	return bl.Token.Literal
+}
+func (bl *BigIntLiteral) Pos() token.Position { return bl.Token.Pos }
+func (bl *BigIntLiteral) End() token.Position { return bl.Token.End }
+func (bl *BigIntLiteral) String() string {
+	return bl.Token.Literal
+}
+
 type FloatLiteral struct {
 	Token token.Token
 	Value float64
@@ -142,10 +279,29 @@ func (fl *FloatLiteral) expressionNode() {}
 func (fl *FloatLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
+func (fl *FloatLiteral) Pos() token.Position { return fl.Token.Pos }
+func (fl *FloatLiteral) End() token.Position { return fl.Token.End }
 func (fl *FloatLiteral) String() string {
 	return fl.Token.Literal
 }
 
+// DecimalLiteral is a number with a "d" suffix. Value is the literal
+// without the suffix, the evaluator turns it into a DECIMAL.
+type DecimalLiteral struct {
+	Token token.Token
+	Value string
+}
+
+func (dl *DecimalLiteral) expressionNode() {}
+func (dl *DecimalLiteral) TokenLiteral() string {
+	return dl.Token.Literal
+}
+func (dl *DecimalLiteral) Pos() token.Position { return dl.Token.Pos }
+func (dl *DecimalLiteral) End() token.Position { return dl.Token.End }
+func (dl *DecimalLiteral) String() string {
+	return dl.Token.Literal
+}
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
@@ -154,6 +310,13 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Position  { return pe.Token.Pos }
+func (pe *PrefixExpression) End() token.Position {
+	if pe.Right != nil {
+		return pe.Right.End()
+	}
+	return pe.Token.End
+}
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -172,6 +335,18 @@ type InfixExpression struct {
 
 func (oe *InfixExpression) expressionNode()      {}
 func (oe *InfixExpression) TokenLiteral() string { return oe.Token.Literal }
+func (oe *InfixExpression) Pos() token.Position {
+	if oe.Left != nil {
+		return oe.Left.Pos()
+	}
+	return oe.Token.Pos
+}
+func (oe *InfixExpression) End() token.Position {
+	if oe.Right != nil {
+		return oe.Right.End()
+	}
+	return oe.Token.End
+}
 func (oe *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -184,6 +359,39 @@ func (oe *InfixExpression) String() string {
 	return out.String()
 }
 
+// AssignExpression assigns to an existing variable or to an element of
+// an array or map. Operator is "=" or a compound one such as "+=".
+type AssignExpression struct {
+	Token    token.Token // the operator token
+	Target   Expression  // *Identifier or *IndexExpression
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode()      {}
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) Pos() token.Position {
+	if ae.Target != nil {
+		return ae.Target.Pos()
+	}
+	return ae.Token.Pos
+}
+func (ae *AssignExpression) End() token.Position {
+	if ae.Value != nil {
+		return ae.Value.End()
+	}
+	return ae.Token.End
+}
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+
+	return out.String()
+}
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -193,10 +401,28 @@ func (b *Boolean) expressionNode() {}
 func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
+func (b *Boolean) Pos() token.Position { return b.Token.Pos }
+func (b *Boolean) End() token.Position { return b.Token.End }
 func (b *Boolean) String() string {
 	return b.Token.Literal
 }
 
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (n *NullLiteral) expressionNode() {}
+func (n *NullLiteral) TokenLiteral() string {
+	return n.Token.Literal
+}
+func (n *NullLiteral) Pos() token.Position { return n.Token.Pos }
+func (n *NullLiteral) End() token.Position { return n.Token.End }
+func (n *NullLiteral) String() string {
+	return n.Token.Literal
+}
+
+// IfExpression is if (cond) { ... } else { ... }. In an else if chain the
+// Alternative holds just the next IfExpression.
 type IfExpression struct {
 	Token       token.Token
 	Condition   Expression
@@ -206,6 +432,16 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() token.Position  { return ie.Token.Pos }
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return ie.Token.End
+}
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -222,13 +458,137 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+// ConditionalExpression is cond ? a : b.
+type ConditionalExpression struct {
+	Token       token.Token // the '?' token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (ce *ConditionalExpression) expressionNode()      {}
+func (ce *ConditionalExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *ConditionalExpression) Pos() token.Position  { return ce.Condition.Pos() }
+func (ce *ConditionalExpression) End() token.Position {
+	if ce.Alternative != nil {
+		return ce.Alternative.End()
+	}
+	return ce.Token.End
+}
+func (ce *ConditionalExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ce.Condition.String())
+	out.WriteString(" ? ")
+	out.WriteString(ce.Consequence.String())
+	out.WriteString(" : ")
+	out.WriteString(ce.Alternative.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// WhileExpression runs Body for as long as Condition is truthy.
+type WhileExpression struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (we *WhileExpression) expressionNode()      {}
+func (we *WhileExpression) TokenLiteral() string { return we.Token.Literal }
+func (we *WhileExpression) Pos() token.Position  { return we.Token.Pos }
+func (we *WhileExpression) End() token.Position {
+	if we.Body != nil {
+		return we.Body.End()
+	}
+	return we.Token.End
+}
+func (we *WhileExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while")
+	out.WriteString(we.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(we.Body.String())
+
+	return out.String()
+}
+
+// ForExpression runs Body once for every element of Iterable. Key is
+// only set in the two variable form, "for (k, v in m) { ... }".
+type ForExpression struct {
+	Token    token.Token
+	Key      *Identifier
+	Value    *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fe *ForExpression) expressionNode()      {}
+func (fe *ForExpression) TokenLiteral() string { return fe.Token.Literal }
+func (fe *ForExpression) Pos() token.Position  { return fe.Token.Pos }
+func (fe *ForExpression) End() token.Position {
+	if fe.Body != nil {
+		return fe.Body.End()
+	}
+	return fe.Token.End
+}
+func (fe *ForExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	if fe.Key != nil {
+		out.WriteString(fe.Key.String() + ", ")
+	}
+	out.WriteString(fe.Value.String())
+	out.WriteString(" in ")
+	out.WriteString(fe.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fe.Body.String())
+
+	return out.String()
+}
+
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) Pos() token.Position  { return bs.Token.Pos }
+func (bs *BreakStatement) End() token.Position  { return bs.Token.End }
+func (bs *BreakStatement) String() string       { return bs.Token.Literal + ";" }
+
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) Pos() token.Position  { return cs.Token.Pos }
+func (cs *ContinueStatement) End() token.Position  { return cs.Token.End }
+func (cs *ContinueStatement) String() string       { return cs.Token.Literal + ";" }
+
 type BlockStatement struct {
-	Token      token.Token
+	Token      token.Token // the '{' token
 	Statements []Statement
+	Rbrace     token.Token
 }
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Position  { return bs.Token.Pos }
+func (bs *BlockStatement) End() token.Position {
+	if bs.Rbrace.End.IsValid() {
+		return bs.Rbrace.End
+	}
+	if len(bs.Statements) > 0 {
+		return bs.Statements[len(bs.Statements)-1].End()
+	}
+	return bs.Token.End
+}
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -247,6 +607,13 @@ type FunctionLiteral struct {
 
 func (fl *FunctionLiteral) expressionNode()      {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Pos() token.Position  { return fl.Token.Pos }
+func (fl *FunctionLiteral) End() token.Position {
+	if fl.Body != nil {
+		return fl.Body.End()
+	}
+	return fl.Token.End
+}
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 	params := []string{}
@@ -265,13 +632,26 @@ func (fl *FunctionLiteral) String() string {
 }
 
 type CallExpression struct {
-	Token     token.Token
+	Token     token.Token // the '(' token
 	Function  Expression
 	Arguments []Expression
+	Rparen    token.Token
 }
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Position {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+	return ce.Token.Pos
+}
+func (ce *CallExpression) End() token.Position {
+	if ce.Rparen.End.IsValid() {
+		return ce.Rparen.End
+	}
+	return ce.Token.End
+}
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 	args := []string{}
@@ -295,15 +675,67 @@ type StringLiteral struct {
 
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
-func (sl *StringLiteral) String() string       { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position  { return sl.Token.Pos }
+func (sl *StringLiteral) End() token.Position  { return sl.Token.End }
+func (sl *StringLiteral) String() string       { return quote(sl.Value) }
+
+// quote returns s as a double quoted rizzy string literal.
+func quote(s string) string {
+	return strings.ReplaceAll(strconv.Quote(s), "${", "\\${")
+}
+
+// InterpolatedString is a string with embedded expressions, such as
+// "hello ${name}". Parts alternates between *StringLiteral segments and
+// the embedded expressions, starting and ending with a segment.
+type InterpolatedString struct {
+	Token token.Token // the INTERP_START token
+	Parts []Expression
+	Close token.Token // the INTERP_END token
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) Pos() token.Position  { return is.Token.Pos }
+func (is *InterpolatedString) End() token.Position {
+	if is.Close.End.IsValid() {
+		return is.Close.End
+	}
+	return is.Token.End
+}
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("\"")
+	for _, part := range is.Parts {
+		if lit, ok := part.(*StringLiteral); ok {
+			quoted := quote(lit.Value)
+			out.WriteString(quoted[1 : len(quoted)-1])
+			continue
+		}
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+	out.WriteString("\"")
+
+	return out.String()
+}
 
 type ArrayLiteral struct {
-	Token    token.Token
+	Token    token.Token // the '[' token
 	Elements []Expression
+	Rbracket token.Token
 }
 
 func (al *ArrayLiteral) expressionNode()      {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() token.Position  { return al.Token.Pos }
+func (al *ArrayLiteral) End() token.Position {
+	if al.Rbracket.End.IsValid() {
+		return al.Rbracket.End
+	}
+	return al.Token.End
+}
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 
@@ -319,38 +751,132 @@ func (al *ArrayLiteral) String() string {
 	return out.String()
 }
 
+// TupleLiteral is a tuple, (1, 2). A tuple with a single element needs a
+// trailing comma, (1,), so it isn't taken for a grouped expression.
+type TupleLiteral struct {
+	Token    token.Token // the '(' token
+	Elements []Expression
+	Rparen   token.Token
+}
+
+func (tl *TupleLiteral) expressionNode()      {}
+func (tl *TupleLiteral) TokenLiteral() string { return tl.Token.Literal }
+func (tl *TupleLiteral) Pos() token.Position  { return tl.Token.Pos }
+func (tl *TupleLiteral) End() token.Position {
+	if tl.Rparen.End.IsValid() {
+		return tl.Rparen.End
+	}
+	return tl.Token.End
+}
+func (tl *TupleLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range tl.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("(")
+	out.WriteString(strings.Join(elements, ", "))
+	if len(elements) == 1 {
+		out.WriteString(",")
+	}
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type IndexExpression struct {
-	Token token.Token
-	Left  Expression
-	Index Expression
+	Token    token.Token // the '[' or '?[' token
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token
+	Optional bool // a?[i] is null instead of an error when a is null
 }
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Pos
+}
+func (ie *IndexExpression) End() token.Position {
+	if ie.Rbracket.End.IsValid() {
+		return ie.Rbracket.End
+	}
+	return ie.Token.End
+}
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
 	return out.String()
 }
 
+// DotExpression is a field access, a.b or a?.b. With ?. it is null
+// instead of an error when a is null.
+type DotExpression struct {
+	Token    token.Token // the '.' or '?.' token
+	Left     Expression
+	Field    *Identifier
+	Optional bool
+}
+
+func (de *DotExpression) expressionNode()      {}
+func (de *DotExpression) TokenLiteral() string { return de.Token.Literal }
+func (de *DotExpression) Pos() token.Position {
+	if de.Left != nil {
+		return de.Left.Pos()
+	}
+	return de.Token.Pos
+}
+func (de *DotExpression) End() token.Position {
+	if de.Field != nil {
+		return de.Field.End()
+	}
+	return de.Token.End
+}
+func (de *DotExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(de.Left.String())
+	out.WriteString(de.Token.Literal)
+	out.WriteString(de.Field.String())
+	out.WriteString(")")
+	return out.String()
+}
+
 // Hash Map
 type MapLiteral struct {
-	Token token.Token
-	Pairs map[Expression]Expression
+	Token  token.Token // the '{' token
+	Pairs  map[Expression]Expression
+	Keys   []Expression // the keys of Pairs in source order
+	Rbrace token.Token
 }
 
 func (ml *MapLiteral) expressionNode()      {}
 func (ml *MapLiteral) TokenLiteral() string { return ml.Token.Literal }
+func (ml *MapLiteral) Pos() token.Position  { return ml.Token.Pos }
+func (ml *MapLiteral) End() token.Position {
+	if ml.Rbrace.End.IsValid() {
+		return ml.Rbrace.End
+	}
+	return ml.Token.End
+}
 func (ml *MapLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range ml.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range ml.Keys {
+		pairs = append(pairs, key.String()+":"+ml.Pairs[key].String())
 	}
 
 	out.WriteString("{")
@@ -359,3 +885,316 @@ func (ml *MapLiteral) String() string {
 
 	return out.String()
 }
+
+// SetLiteral is a set, {1, 2, 3}. {} is an empty map, not a set.
+type SetLiteral struct {
+	Token    token.Token // the '{' token
+	Elements []Expression
+	Rbrace   token.Token
+}
+
+func (sl *SetLiteral) expressionNode()      {}
+func (sl *SetLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *SetLiteral) Pos() token.Position  { return sl.Token.Pos }
+func (sl *SetLiteral) End() token.Position {
+	if sl.Rbrace.End.IsValid() {
+		return sl.Rbrace.End
+	}
+	return sl.Token.End
+}
+func (sl *SetLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range sl.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// EnumStatement declares an enum type with its variants:
+//
+//	enum Shape { Circle(r), Rect(w, h), Empty }
+type EnumStatement struct {
+	Token    token.Token // the 'enum' token
+	Name     *Identifier
+	Variants []*EnumVariant
+	Rbrace   token.Token
+}
+
+// EnumVariant is a variant of an enum. A variant with Fields carries a
+// value for each of them, one without is a single value.
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (v *EnumVariant) String() string {
+	if v.Fields == nil {
+		return v.Name.String()
+	}
+	fields := []string{}
+	for _, f := range v.Fields {
+		fields = append(fields, f.String())
+	}
+	return v.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
+func (es *EnumStatement) statementNode()       {}
+func (es *EnumStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *EnumStatement) Pos() token.Position  { return es.Token.Pos }
+func (es *EnumStatement) End() token.Position {
+	if es.Rbrace.End.IsValid() {
+		return es.Rbrace.End
+	}
+	return es.Token.End
+}
+func (es *EnumStatement) String() string {
+	var out bytes.Buffer
+
+	variants := []string{}
+	for _, v := range es.Variants {
+		variants = append(variants, v.String())
+	}
+
+	out.WriteString("enum ")
+	out.WriteString(es.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(variants, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// MatchExpression evaluates the body of the first arm whose pattern
+// matches Subject and whose guard, if it has one, is truthy.
+type MatchExpression struct {
+	Token   token.Token // the 'match' token
+	Subject Expression
+	Arms    []*MatchArm
+	Rbrace  token.Token
+}
+
+// MatchArm is `pattern if guard => body`. Guard is nil without an if.
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression
+	Body    *BlockStatement
+}
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
+
+func (me *MatchExpression) expressionNode()      {}
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MatchExpression) Pos() token.Position  { return me.Token.Pos }
+func (me *MatchExpression) End() token.Position {
+	if me.Rbrace.End.IsValid() {
+		return me.Rbrace.End
+	}
+	return me.Token.End
+}
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+
+	out.WriteString("match ")
+	out.WriteString(me.Subject.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// Pattern is what a value is matched against in a match arm.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// WildcardPattern, _, matches anything.
+type WildcardPattern struct {
+	Token token.Token
+}
+
+func (wp *WildcardPattern) patternNode()         {}
+func (wp *WildcardPattern) TokenLiteral() string { return wp.Token.Literal }
+func (wp *WildcardPattern) Pos() token.Position  { return wp.Token.Pos }
+func (wp *WildcardPattern) End() token.Position  { return wp.Token.End }
+func (wp *WildcardPattern) String() string       { return "_" }
+
+// BindingPattern matches anything and binds it to Name.
+type BindingPattern struct {
+	Name *Identifier
+}
+
+func (bp *BindingPattern) patternNode()         {}
+func (bp *BindingPattern) TokenLiteral() string { return bp.Name.TokenLiteral() }
+func (bp *BindingPattern) Pos() token.Position  { return bp.Name.Pos() }
+func (bp *BindingPattern) End() token.Position  { return bp.Name.End() }
+func (bp *BindingPattern) String() string       { return bp.Name.String() }
+
+// LiteralPattern matches values equal to a literal, like 1, "a" or null.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode()         {}
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Value.TokenLiteral() }
+func (lp *LiteralPattern) Pos() token.Position  { return lp.Value.Pos() }
+func (lp *LiteralPattern) End() token.Position  { return lp.Value.End() }
+func (lp *LiteralPattern) String() string       { return lp.Value.String() }
+
+// RangePattern, low..high, matches values from low to high, both
+// included.
+type RangePattern struct {
+	Low  Expression
+	High Expression
+}
+
+func (rp *RangePattern) patternNode()         {}
+func (rp *RangePattern) TokenLiteral() string { return rp.Low.TokenLiteral() }
+func (rp *RangePattern) Pos() token.Position  { return rp.Low.Pos() }
+func (rp *RangePattern) End() token.Position  { return rp.High.End() }
+func (rp *RangePattern) String() string {
+	return rp.Low.String() + ".." + rp.High.String()
+}
+
+// ArrayPattern matches arrays element by element. Without a rest the
+// array must have exactly as many elements as the pattern, with a rest,
+// [a, ..rest], it may have more and Rest is bound to an array of them.
+type ArrayPattern struct {
+	Token    token.Token // the '[' token
+	Elements []Pattern
+	HasRest  bool
+	Rest     *Identifier // nil for a rest without a name, [a, ..]
+	Rbracket token.Token
+}
+
+func (ap *ArrayPattern) patternNode()         {}
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+func (ap *ArrayPattern) Pos() token.Position  { return ap.Token.Pos }
+func (ap *ArrayPattern) End() token.Position {
+	if ap.Rbracket.End.IsValid() {
+		return ap.Rbracket.End
+	}
+	return ap.Token.End
+}
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.HasRest {
+		rest := ".."
+		if ap.Rest != nil {
+			rest += ap.Rest.String()
+		}
+		elements = append(elements, rest)
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// TuplePattern matches tuples with as many elements, element by element.
+type TuplePattern struct {
+	Token    token.Token // the '(' token
+	Elements []Pattern
+	Rparen   token.Token
+}
+
+func (tp *TuplePattern) patternNode()         {}
+func (tp *TuplePattern) TokenLiteral() string { return tp.Token.Literal }
+func (tp *TuplePattern) Pos() token.Position  { return tp.Token.Pos }
+func (tp *TuplePattern) End() token.Position {
+	if tp.Rparen.End.IsValid() {
+		return tp.Rparen.End
+	}
+	return tp.Token.End
+}
+func (tp *TuplePattern) String() string {
+	elements := []string{}
+	for _, el := range tp.Elements {
+		elements = append(elements, el.String())
+	}
+	if len(elements) == 1 {
+		return "(" + elements[0] + ",)"
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+// MapPattern matches maps that have all of its keys, with values that
+// match the patterns of the keys. The map may have other keys too.
+type MapPattern struct {
+	Token  token.Token // the '{' token
+	Keys   []Expression
+	Values []Pattern
+	Rbrace token.Token
+}
+
+func (mp *MapPattern) patternNode()         {}
+func (mp *MapPattern) TokenLiteral() string { return mp.Token.Literal }
+func (mp *MapPattern) Pos() token.Position  { return mp.Token.Pos }
+func (mp *MapPattern) End() token.Position {
+	if mp.Rbrace.End.IsValid() {
+		return mp.Rbrace.End
+	}
+	return mp.Token.End
+}
+func (mp *MapPattern) String() string {
+	pairs := []string{}
+	for i, key := range mp.Keys {
+		pairs = append(pairs, key.String()+": "+mp.Values[i].String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// ConstructorPattern matches a variant of an enum, Shape.Circle(r) or
+// Shape.Empty, or a struct, Point(x, y). Args match the fields of the
+// value in order. Without parentheses the fields aren't looked at.
+type ConstructorPattern struct {
+	Type    Expression // an *Identifier or a *DotExpression
+	Args    []Pattern
+	HasArgs bool
+	Rparen  token.Token
+}
+
+func (cp *ConstructorPattern) patternNode()         {}
+func (cp *ConstructorPattern) TokenLiteral() string { return cp.Type.TokenLiteral() }
+func (cp *ConstructorPattern) Pos() token.Position  { return cp.Type.Pos() }
+func (cp *ConstructorPattern) End() token.Position {
+	if cp.Rparen.End.IsValid() {
+		return cp.Rparen.End
+	}
+	return cp.Type.End()
+}
+func (cp *ConstructorPattern) String() string {
+	if !cp.HasArgs {
+		return cp.Type.String()
+	}
+	args := []string{}
+	for _, arg := range cp.Args {
+		args = append(args, arg.String())
+	}
+	return cp.Type.String() + "(" + strings.Join(args, ", ") + ")"
+}
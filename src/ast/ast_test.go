@@ -27,3 +27,14 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong, got %q", program.String())
 	}
 }
+
+func TestStringLiteralString(t *testing.T) {
+	lit := &StringLiteral{
+		Token: token.Token{Type: token.STRING, Literal: "a\"b\n\\"},
+		Value: "a\"b\n\\",
+	}
+
+	if lit.String() != `"a\"b\n\\"` {
+		t.Errorf("lit.String() wrong, got %q", lit.String())
+	}
+}
@@ -0,0 +1,282 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/batt0s/rizzy/object"
+)
+
+// CheckedArithmetic makes integer operations that overflow INTEGER
+// return an error instead of switching to a BIGINT.
+var CheckedArithmetic = false
+
+// DecimalContext is the precision and rounding mode of DECIMAL
+// arithmetic.
+var DecimalContext = object.DefaultDecimalContext
+
+// maxBigIntBits limits how large ** and << can make a BIGINT, so a typo
+// like 2 ** 10000000000 fails instead of using up all memory.
+const maxBigIntBits = 1 << 24
+
+// integerResult wraps the result of an integer operation. On overflow
+// the operation is redone with BIGINTs, or an error is returned when
+// CheckedArithmetic is on.
+func integerResult(value int64, ok bool, left int64, operator string, right int64) object.Object {
+	if ok {
+		return &object.Integer{Value: value}
+	}
+	if CheckedArithmetic {
+		return newError("integer overflow: %d %s %d", left, operator, right)
+	}
+	return evalBigIntInfixExpression(operator,
+		&object.Integer{Value: left}, &object.Integer{Value: right})
+}
+
+// isInteger tells whether obj is an INTEGER or a BIGINT.
+func isInteger(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.BIGINT_OBJ
+}
+
+// bigIntValue returns the value of an INTEGER or BIGINT.
+func bigIntValue(obj object.Object) *big.Int {
+	if i, ok := obj.(*object.Integer); ok {
+		return big.NewInt(i.Value)
+	}
+	return obj.(*object.BigInt).Value
+}
+
+// decimalValue returns the value of an INTEGER, BIGINT or DECIMAL as a
+// DECIMAL.
+func decimalValue(obj object.Object) *object.Decimal {
+	if d, ok := obj.(*object.Decimal); ok {
+		return d
+	}
+	return object.NewDecimal(bigIntValue(obj))
+}
+
+// isDecimalOperand tells whether obj can take part in DECIMAL
+// arithmetic, that is whether it is a DECIMAL or an integer.
+func isDecimalOperand(obj object.Object) bool {
+	return obj.Type() == object.DECIMAL_OBJ || isInteger(obj)
+}
+
+// isFloatOperand tells whether obj can take part in FLOAT arithmetic,
+// that is whether it is a FLOAT or an integer.
+func isFloatOperand(obj object.Object) bool {
+	return obj.Type() == object.FLOAT_OBJ || isInteger(obj)
+}
+
+// floatValue returns the value of a FLOAT, INTEGER or BIGINT as a float64.
+func floatValue(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Float:
+		return obj.Value
+	case *object.Integer:
+		return float64(obj.Value)
+	default:
+		f, _ := new(big.Float).SetInt(bigIntValue(obj)).Float64()
+		return f
+	}
+}
+
+// integerObject returns i as an INTEGER if it fits in one, as a BIGINT
+// otherwise.
+func integerObject(i *big.Int) object.Object {
+	if i.IsInt64() {
+		return &object.Integer{Value: i.Int64()}
+	}
+	return &object.BigInt{Value: i}
+}
+
+// evalBigIntInfixExpression does integer arithmetic without overflow. A
+// result that fits in an INTEGER is one, so 2 ** 64 / 2 ** 60 is 16.
+func evalBigIntInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := bigIntValue(left)
+	rightVal := bigIntValue(right)
+
+	switch operator {
+	case "+":
+		return integerObject(new(big.Int).Add(leftVal, rightVal))
+	case "-":
+		return integerObject(new(big.Int).Sub(leftVal, rightVal))
+	case "*":
+		return integerObject(new(big.Int).Mul(leftVal, rightVal))
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return integerObject(new(big.Int).Quo(leftVal, rightVal))
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("modulo by zero")
+		}
+		return integerObject(new(big.Int).Rem(leftVal, rightVal))
+	case "**":
+		if rightVal.Sign() < 0 {
+			base, _ := new(big.Float).SetInt(leftVal).Float64()
+			exp, _ := new(big.Float).SetInt(rightVal).Float64()
+			return &object.Float{Value: math.Pow(base, exp)}
+		}
+		if leftVal.CmpAbs(big.NewInt(1)) > 0 &&
+			(!rightVal.IsInt64() || rightVal.Int64() > maxBigIntBits/int64(leftVal.BitLen())) {
+			return newError("integer too large: %s ** %s", leftVal, rightVal)
+		}
+		return integerObject(new(big.Int).Exp(leftVal, rightVal, nil))
+	case "<":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case "<=":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case ">=":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) != 0)
+	case "&":
+		return integerObject(new(big.Int).And(leftVal, rightVal))
+	case "|":
+		return integerObject(new(big.Int).Or(leftVal, rightVal))
+	case "^":
+		return integerObject(new(big.Int).Xor(leftVal, rightVal))
+	case "<<", ">>":
+		if rightVal.Sign() < 0 {
+			return newError("negative shift count: %s", rightVal)
+		}
+		if operator == ">>" {
+			n := uint(maxBigIntBits)
+			if rightVal.IsInt64() && rightVal.Int64() < maxBigIntBits {
+				n = uint(rightVal.Int64())
+			}
+			return integerObject(new(big.Int).Rsh(leftVal, n))
+		}
+		if leftVal.Sign() != 0 &&
+			(!rightVal.IsInt64() || int64(leftVal.BitLen())+rightVal.Int64() > maxBigIntBits) {
+			return newError("integer too large: %s << %s", leftVal, rightVal)
+		}
+		return integerObject(new(big.Int).Lsh(leftVal, uint(rightVal.Int64())))
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+func evalDecimalInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := decimalValue(left)
+	rightVal := decimalValue(right)
+
+	switch operator {
+	case "+":
+		return leftVal.Add(rightVal, DecimalContext)
+	case "-":
+		return leftVal.Sub(rightVal, DecimalContext)
+	case "*":
+		return leftVal.Mul(rightVal, DecimalContext)
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		return leftVal.Quo(rightVal, DecimalContext)
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("modulo by zero")
+		}
+		return leftVal.Rem(rightVal, DecimalContext)
+	case "**":
+		if !rightVal.IsInteger() {
+			return newError("exponent of a DECIMAL must be an integer, got %s", right.Inspect())
+		}
+		exp := rightVal.Int()
+		if exp.Sign() < 0 && leftVal.Sign() == 0 {
+			return newError("division by zero")
+		}
+		n := new(big.Int).Abs(exp)
+		if leftVal.IsInteger() && leftVal.Int().CmpAbs(big.NewInt(1)) <= 0 {
+			// 0, 1 and -1 stay small whatever the exponent.
+			return object.NewDecimal(new(big.Int).Exp(leftVal.Int(), n, nil))
+		}
+		// Both the digits and the scale of the result grow with |exp|.
+		scale := int64(leftVal.Scale)
+		if scale < 0 {
+			scale = -scale
+		}
+		if !n.IsInt64() || n.Int64() > maxBigIntBits/int64(leftVal.Unscaled.BitLen()) ||
+			n.Int64()*scale > maxBigIntBits {
+			return newError("decimal too large: %s ** %s", left.Inspect(), right.Inspect())
+		}
+		return leftVal.Pow(exp.Int64(), DecimalContext)
+	case "<":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case "<=":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case ">=":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBooltoBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s",
+			left.Type(), operator, right.Type())
+	}
+}
+
+// The functions below compute the wrapped around result and report
+// whether it is exact.
+
+func addInt(a, b int64) (int64, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+func subInt(a, b int64) (int64, bool) {
+	c := a - b
+	return c, (c < a) == (b > 0)
+}
+
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return c, false
+	}
+	return c, c/b == a
+}
+
+func divInt(a, b int64) (int64, bool) {
+	return a / b, !(a == math.MinInt64 && b == -1)
+}
+
+func shlInt(a, n int64) (int64, bool) {
+	if n >= 64 {
+		return 0, a == 0
+	}
+	c := a << uint64(n)
+	return c, c>>uint64(n) == a
+}
+
+// intPow computes base ** exp for exp >= 0 by repeated squaring, so
+// the result is exact instead of going through float64.
+func intPow(base, exp int64) (int64, bool) {
+	result := int64(1)
+	exact := true
+	for exp > 0 {
+		var ok bool
+		if exp&1 == 1 {
+			result, ok = mulInt(result, base)
+			exact = exact && ok
+		}
+		exp >>= 1
+		if exp > 0 {
+			base, ok = mulInt(base, base)
+			exact = exact && ok
+		}
+	}
+	return result, exact
+}
@@ -3,7 +3,9 @@ package evaluator
 import (
 	"fmt"
 	"math"
+	"math/big"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -26,12 +28,36 @@ var builtins = map[string]*object.Builtin{
 	"push":  &object.Builtin{Fn: builtin_push},
 	"pop":   &object.Builtin{Fn: builtin_pop},
 	"range": &object.Builtin{Fn: builtin_range},
+	"sort":  &object.Builtin{Fn: builtin_sort},
+	// String Operations
+	"upper": &object.Builtin{Fn: builtin_upper},
+	"lower": &object.Builtin{Fn: builtin_lower},
+	"trim":  &object.Builtin{Fn: builtin_trim},
+	"split": &object.Builtin{Fn: builtin_split},
+	"join":  &object.Builtin{Fn: builtin_join},
+	// Map Operations
+	"keys":   &object.Builtin{Fn: builtin_keys},
+	"values": &object.Builtin{Fn: builtin_values},
+	"items":  &object.Builtin{Fn: builtin_items},
+	"has":    &object.Builtin{Fn: builtin_has},
+	"delete": &object.Builtin{Fn: builtin_delete},
+	"merge":  &object.Builtin{Fn: builtin_merge},
+	// Set Operations
+	"set": &object.Builtin{Fn: builtin_set},
+	"add": &object.Builtin{Fn: builtin_add},
+	// Tuples and Freezing
+	"tuple":  &object.Builtin{Fn: builtin_tuple},
+	"freeze": &object.Builtin{Fn: builtin_freeze},
 	// Math
 	"pow":  &object.Builtin{Fn: builtin_pow},
 	"sqrt": &object.Builtin{Fn: builtin_sqrt},
 	// Types
-	"int":   &object.Builtin{Fn: builtin_int},
-	"float": &object.Builtin{Fn: builtin_float},
+	"int":     &object.Builtin{Fn: builtin_int},
+	"float":   &object.Builtin{Fn: builtin_float},
+	"decimal": &object.Builtin{Fn: builtin_decimal},
+	// Decimal context
+	"setprecision": &object.Builtin{Fn: builtin_setprecision},
+	"setrounding":  &object.Builtin{Fn: builtin_setrounding},
 }
 
 func builtin_type(args ...object.Object) object.Object {
@@ -90,6 +116,12 @@ func builtin_len(args ...object.Object) object.Object {
 		return &object.Integer{Value: int64(len(arg.Elements))}
 	case *object.String:
 		return &object.Integer{Value: int64(len(arg.Value))}
+	case *object.Map:
+		return &object.Integer{Value: int64(arg.Len())}
+	case *object.Set:
+		return &object.Integer{Value: int64(arg.Len())}
+	case *object.Tuple:
+		return &object.Integer{Value: int64(len(arg.Elements))}
 	default:
 		return newError("argument to `len` not supported, got %s", args[0].Type())
 	}
@@ -194,6 +226,88 @@ func builtin_push(args ...object.Object) object.Object {
 	return &object.Array{Elements: newElements}
 }
 
+func builtin_upper(args ...object.Object) object.Object {
+	return stringFunction("upper", strings.ToUpper, args)
+}
+
+func builtin_lower(args ...object.Object) object.Object {
+	return stringFunction("lower", strings.ToLower, args)
+}
+
+func builtin_trim(args ...object.Object) object.Object {
+	return stringFunction("trim", strings.TrimSpace, args)
+}
+
+// stringFunction applies fn to the single STRING argument of the builtin
+// called name.
+func stringFunction(name string, fn func(string) string, args []object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	str, ok := args[0].(*object.String)
+	if !ok {
+		return newError("argument to `%s` must be STRING, got %s", name, args[0].Type())
+	}
+
+	return &object.String{Value: fn(str.Value)}
+}
+
+// builtin_split splits a string around a separator, or around runs of
+// whitespace without one.
+func builtin_split(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	for _, arg := range args {
+		if arg.Type() != object.STRING_OBJ {
+			return newError("argument to `split` must be STRING, got %s", arg.Type())
+		}
+	}
+
+	var parts []string
+	if len(args) == 1 {
+		parts = strings.Fields(args[0].(*object.String).Value)
+	} else {
+		parts = strings.Split(args[0].(*object.String).Value, args[1].(*object.String).Value)
+	}
+
+	elements := make([]object.Object, len(parts))
+	for i, part := range parts {
+		elements[i] = &object.String{Value: part}
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+// builtin_join joins an array of strings with a separator.
+func builtin_join(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("argument to `join` must be ARRAY, got %s", args[0].Type())
+	}
+	sep, ok := args[1].(*object.String)
+	if !ok {
+		return newError("separator of `join` must be STRING, got %s", args[1].Type())
+	}
+
+	parts := make([]string, len(arr.Elements))
+	for i, el := range arr.Elements {
+		str, ok := el.(*object.String)
+		if !ok {
+			return newError("cannot join %s, only STRINGs", el.Type())
+		}
+		parts[i] = str.Value
+	}
+
+	return &object.String{Value: strings.Join(parts, sep.Value)}
+}
+
 func builtin_pop(args ...object.Object) object.Object {
 	if len(args) < 1 || len(args) > 2 {
 		return newError("wrong number of arguments. got=%d, want=1 or 2",
@@ -229,6 +343,266 @@ func builtin_pop(args ...object.Object) object.Object {
 	return &object.Array{Elements: newElements}
 }
 
+func builtin_sort(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+
+	if args[0].Type() != object.ARRAY_OBJ {
+		return newError("argument to `sort` must be ARRAY, got %s",
+			args[0].Type())
+	}
+
+	arr := args[0].(*object.Array)
+	length := len(arr.Elements)
+
+	newElements := make([]object.Object, length, length)
+	copy(newElements, arr.Elements)
+
+	var err object.Object
+	sort.SliceStable(newElements, func(i, j int) bool {
+		c, ok := object.Compare(newElements[i], newElements[j])
+		if !ok && err == nil {
+			err = newError("cannot compare %s and %s in `sort`",
+				newElements[i].Inspect(), newElements[j].Inspect())
+		}
+		return c < 0
+	})
+	if err != nil {
+		return err
+	}
+
+	return &object.Array{Elements: newElements}
+}
+
+// Map Operations
+func builtin_keys(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	if args[0].Type() != object.MAP_OBJ {
+		return newError("argument to `keys` must be MAP, got %s",
+			args[0].Type())
+	}
+
+	pairs := args[0].(*object.Map).Pairs()
+	elements := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		elements[i] = pair.Key
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func builtin_values(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	if args[0].Type() != object.MAP_OBJ {
+		return newError("argument to `values` must be MAP, got %s",
+			args[0].Type())
+	}
+
+	pairs := args[0].(*object.Map).Pairs()
+	elements := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		elements[i] = pair.Value
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func builtin_items(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	if args[0].Type() != object.MAP_OBJ {
+		return newError("argument to `items` must be MAP, got %s",
+			args[0].Type())
+	}
+
+	pairs := args[0].(*object.Map).Pairs()
+	elements := make([]object.Object, len(pairs))
+	for i, pair := range pairs {
+		elements[i] = &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func builtin_has(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	key, ok := object.AsHashable(args[1])
+	if !ok {
+		return newError("unusable as hash key: %s", args[1].Type())
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Map:
+		_, found := arg.Get(key)
+		return nativeBooltoBooleanObject(found)
+	case *object.Set:
+		return nativeBooltoBooleanObject(arg.Has(key))
+	default:
+		return newError("argument to `has` must be MAP or SET, got %s",
+			args[0].Type())
+	}
+}
+
+// builtin_delete removes a key from a map or an element from a set in
+// place and returns the value it had, or null if it wasn't there.
+func builtin_delete(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+
+	key, ok := object.AsHashable(args[1])
+	if !ok {
+		return newError("unusable as hash key: %s", args[1].Type())
+	}
+
+	var value object.Object
+	var found bool
+	switch arg := args[0].(type) {
+	case *object.Map:
+		if arg.Frozen {
+			return newError("cannot modify frozen MAP")
+		}
+		value, found = arg.Delete(key)
+	case *object.Set:
+		if arg.Frozen {
+			return newError("cannot modify frozen SET")
+		}
+		value, found = arg.Remove(key)
+	default:
+		return newError("argument to `delete` must be MAP or SET, got %s",
+			args[0].Type())
+	}
+	if !found {
+		return NULL
+	}
+
+	return value
+}
+
+// builtin_set returns a new set with the elements of an array, set or
+// string, or the keys of a map. Without an argument the set is empty.
+func builtin_set(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+	}
+
+	set := object.NewSet()
+	if len(args) == 0 {
+		return set
+	}
+
+	keys, values, err := iterationItems(args[0])
+	if err != nil {
+		return err
+	}
+	if args[0].Type() == object.MAP_OBJ {
+		values = keys
+	}
+
+	for _, value := range values {
+		element, ok := object.AsHashable(value)
+		if !ok {
+			return newError("unusable as set element: %s", value.Type())
+		}
+		set.Add(element)
+	}
+
+	return set
+}
+
+// builtin_tuple returns a new tuple with the elements of an array, tuple,
+// set or string, or the keys of a map. Without an argument the tuple is
+// empty.
+func builtin_tuple(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return newError("wrong number of arguments. got=%d, want=0 or 1", len(args))
+	}
+
+	if len(args) == 0 {
+		return &object.Tuple{}
+	}
+
+	keys, values, err := iterationItems(args[0])
+	if err != nil {
+		return err
+	}
+	if args[0].Type() == object.MAP_OBJ {
+		values = keys
+	}
+
+	return &object.Tuple{Elements: values}
+}
+
+// builtin_freeze makes an array, map or set and everything in it
+// immutable, and returns it. Changing it afterwards is an error.
+func builtin_freeze(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	object.Freeze(args[0])
+	return args[0]
+}
+
+// builtin_add adds elements to a set in place and returns the set.
+func builtin_add(args ...object.Object) object.Object {
+	if len(args) < 2 {
+		return newError("wrong number of arguments. got=%d, want at least 2", len(args))
+	}
+
+	set, ok := args[0].(*object.Set)
+	if !ok {
+		return newError("argument to `add` must be SET, got %s", args[0].Type())
+	}
+	if set.Frozen {
+		return newError("cannot modify frozen SET")
+	}
+
+	for _, arg := range args[1:] {
+		element, ok := object.AsHashable(arg)
+		if !ok {
+			return newError("unusable as set element: %s", arg.Type())
+		}
+		set.Add(element)
+	}
+
+	return set
+}
+
+// builtin_merge returns a new map with the pairs of all given maps. When
+// several maps have the same key, the value of the last one wins.
+func builtin_merge(args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError("wrong number of arguments. got=%d, want at least 1", len(args))
+	}
+
+	merged := object.NewMap()
+	for _, arg := range args {
+		m, ok := arg.(*object.Map)
+		if !ok {
+			return newError("argument to `merge` must be MAP, got %s", arg.Type())
+		}
+		for _, pair := range m.Pairs() {
+			merged.Set(pair.Key.(object.Hashable), pair.Value)
+		}
+	}
+
+	return merged
+}
+
 // Math
 func builtin_pow(args ...object.Object) object.Object {
 	if len(args) != 2 {
@@ -241,17 +615,16 @@ func builtin_pow(args ...object.Object) object.Object {
 			args[0].Type(), args[1].Type())
 	}
 
-	n := float64(args[0].(*object.Integer).Value)
-	pow := float64(args[1].(*object.Integer).Value)
+	n := args[0].(*object.Integer).Value
+	pow := args[1].(*object.Integer).Value
 
 	if pow < 0 {
 		return newError("argument to `pow` must be positive, got %s",
 			args[1].Inspect())
 	}
 
-	result := math.Pow(n, pow)
-	resultObj := &object.Integer{Value: int64(result)}
-	return resultObj
+	value, ok := intPow(n, pow)
+	return integerResult(value, ok, n, "**", pow)
 }
 
 func builtin_sqrt(args ...object.Object) object.Object {
@@ -288,7 +661,9 @@ func builtin_int(args ...object.Object) object.Object {
 		switch args[0].Type() {
 		case
 			object.INTEGER_OBJ,
+			object.BIGINT_OBJ,
 			object.FLOAT_OBJ,
+			object.DECIMAL_OBJ,
 			object.STRING_OBJ,
 			object.BOOLEAN_OBJ:
 			return true
@@ -297,41 +672,49 @@ func builtin_int(args ...object.Object) object.Object {
 	}
 
 	if !isValidType() {
-		return newError("argument to `int` must be INTEGER, FLOAT, BOOLEAN or STRING, got %s",
+		return newError("argument to `int` must be INTEGER, BIGINT, FLOAT, DECIMAL, BOOLEAN or STRING, got %s",
 			args[0].Type())
 	}
 
-	var returnValue int64
+	// The result is an INTEGER if it fits in one, a BIGINT otherwise.
+	var returnValue *big.Int
 
-	if args[0].Type() == object.INTEGER_OBJ {
-		returnValue = args[0].(*object.Integer).Value
+	if isInteger(args[0]) {
+		returnValue = bigIntValue(args[0])
 	}
 
 	if args[0].Type() == object.FLOAT_OBJ {
 		n := args[0].(*object.Float).Value
-		returnValue = int64(n)
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return newError("cannot convert %s to int", args[0].Inspect())
+		}
+		returnValue, _ = big.NewFloat(n).Int(nil)
+	}
+
+	if args[0].Type() == object.DECIMAL_OBJ {
+		returnValue = args[0].(*object.Decimal).Int()
 	}
 
 	if args[0].Type() == object.BOOLEAN_OBJ {
 		n := args[0].(*object.Boolean).Value
 		switch n {
 		case true:
-			returnValue = int64(1)
+			returnValue = big.NewInt(1)
 		case false:
-			returnValue = int64(0)
+			returnValue = big.NewInt(0)
 		}
 	}
 
 	if args[0].Type() == object.STRING_OBJ {
 		s := args[0].(*object.String).Value
-		n, err := strconv.Atoi(s)
-		if err != nil {
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
 			return newError("error parsing int, check given string")
 		}
-		returnValue = int64(n)
+		returnValue = n
 	}
 
-	return &object.Integer{Value: returnValue}
+	return integerObject(returnValue)
 
 }
 
@@ -345,7 +728,9 @@ func builtin_float(args ...object.Object) object.Object {
 		switch args[0].Type() {
 		case
 			object.INTEGER_OBJ,
+			object.BIGINT_OBJ,
 			object.FLOAT_OBJ,
+			object.DECIMAL_OBJ,
 			object.STRING_OBJ:
 			return true
 		}
@@ -353,7 +738,7 @@ func builtin_float(args ...object.Object) object.Object {
 	}
 
 	if !isValidType() {
-		return newError("argument to `float` must be INTEGER, FLOAT or STRING, got %s",
+		return newError("argument to `float` must be INTEGER, BIGINT, FLOAT, DECIMAL or STRING, got %s",
 			args[0].Type())
 	}
 
@@ -364,10 +749,18 @@ func builtin_float(args ...object.Object) object.Object {
 		returnValue = float64(n)
 	}
 
+	if args[0].Type() == object.BIGINT_OBJ {
+		returnValue = floatValue(args[0])
+	}
+
 	if args[0].Type() == object.FLOAT_OBJ {
 		returnValue = args[0].(*object.Float).Value
 	}
 
+	if args[0].Type() == object.DECIMAL_OBJ {
+		returnValue = args[0].(*object.Decimal).Float64()
+	}
+
 	if args[0].Type() == object.STRING_OBJ {
 		s := args[0].(*object.String).Value
 		n, err := strconv.ParseFloat(s, 64)
@@ -381,6 +774,78 @@ func builtin_float(args ...object.Object) object.Object {
 
 }
 
+func builtin_decimal(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Integer, *object.BigInt, *object.Decimal:
+		return decimalValue(arg)
+	case *object.Float:
+		if math.IsNaN(arg.Value) || math.IsInf(arg.Value, 0) {
+			return newError("cannot convert %s to decimal", arg.Inspect())
+		}
+		// Use the shortest digits that read back as the float, so
+		// decimal(0.1) is 0.1 and not 0.1000000000000000055511151231257827.
+		d, _ := object.ParseDecimal(strconv.FormatFloat(arg.Value, 'g', -1, 64))
+		return d
+	case *object.String:
+		d, ok := object.ParseDecimal(strings.TrimSpace(arg.Value))
+		if !ok {
+			return newError("error parsing decimal, check given string")
+		}
+		return d
+	default:
+		return newError("argument to `decimal` must be INTEGER, BIGINT, FLOAT, DECIMAL or STRING, got %s",
+			args[0].Type())
+	}
+}
+
+// Decimal context
+func builtin_setprecision(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+
+	if args[0].Type() != object.INTEGER_OBJ {
+		return newError("argument to `setprecision` must be INTEGER, got %s",
+			args[0].Type())
+	}
+
+	precision := args[0].(*object.Integer).Value
+	if precision < 1 || precision > math.MaxInt32 {
+		return newError("argument to `setprecision` must be positive, got %s",
+			args[0].Inspect())
+	}
+
+	DecimalContext.Precision = int(precision)
+	return NULL
+}
+
+func builtin_setrounding(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+
+	if args[0].Type() != object.STRING_OBJ {
+		return newError("argument to `setrounding` must be STRING, got %s",
+			args[0].Type())
+	}
+
+	mode, ok := object.ParseRoundingMode(args[0].(*object.String).Value)
+	if !ok {
+		return newError("unknown rounding mode %q, want half_even, half_up, half_down, up, down, ceiling or floor",
+			args[0].(*object.String).Value)
+	}
+
+	DecimalContext.Rounding = mode
+	return NULL
+}
+
 func builtin_range(args ...object.Object) object.Object {
 	if len(args) < 2 || len(args) > 3 {
 		return newError("wrong number of arguments. got=%d, want=2 or 3",
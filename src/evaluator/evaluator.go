@@ -3,18 +3,83 @@ package evaluator
 import (
 	"fmt"
 	"math"
+	"math/big"
+	"strings"
 
 	"github.com/batt0s/rizzy/ast"
 	"github.com/batt0s/rizzy/object"
 )
 
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL     = &object.Null{}
+	TRUE     = &object.Boolean{Value: true}
+	FALSE    = &object.Boolean{Value: false}
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
+// MaxCallDepth limits how deeply functions can call each other, so
+// runaway recursion is reported as an error before it overflows the Go
+// stack, which cannot be recovered from.
+var MaxCallDepth = 10000
+
+// The state of the evaluation in progress. It is package-level, so Eval
+// is not safe for concurrent use: run one evaluation at a time. The
+// settings CheckedArithmetic and DecimalContext are shared the same way.
+var (
+	// calls are the function calls in progress, outermost first.
+	calls []object.Frame
+
+	// evaluating is the innermost node Eval is in. A Go panic unwinds
+	// Eval without restoring it, so it is left at the node the panic
+	// happened in.
+	evaluating ast.Node
+)
+
+// Eval evaluates node in env. It is not safe for concurrent use.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	outer := evaluating
+	evaluating = node
+	result := eval(node, env)
+	evaluating = outer
+
+	// The innermost node an error comes out of is where it happened.
+	if err, ok := result.(*object.Error); ok && node != nil && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+		err.End = node.End()
+	}
+
+	return result
+}
+
+// Recovered turns a Go panic out of Eval into an error at the node it
+// happened in, so a bug in the interpreter doesn't crash the whole
+// session. Callers of Eval recover with it:
+//
+//	defer func() {
+//		if r := recover(); r != nil {
+//			evaluated = evaluator.Recovered(r)
+//		}
+//	}()
+func Recovered(r interface{}) *object.Error {
+	err := &object.Error{Message: fmt.Sprintf("internal error: %v", r)}
+	if evaluating != nil {
+		err.Pos = evaluating.Pos()
+		err.End = evaluating.End()
+	}
+
+	// The panic skipped the code that records the stack, innermost
+	// call first, and unwinds the state.
+	for i := len(calls) - 1; i >= 0; i-- {
+		err.Stack = append(err.Stack, calls[i])
+	}
+	evaluating = nil
+	calls = nil
+
+	return err
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	// Statements
 	case *ast.Program:
@@ -24,10 +89,20 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	// Expressions
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.BigIntLiteral:
+		return &object.BigInt{Value: node.Value}
+	case *ast.DecimalLiteral:
+		value, ok := object.ParseDecimal(node.Value)
+		if !ok {
+			return newError("malformed decimal literal %q", node.Token.Literal)
+		}
+		return value
 	case *ast.FloatLiteral:
 		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
 		return nativeBooltoBooleanObject(node.Value)
+	case *ast.NullLiteral:
+		return NULL
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
@@ -35,6 +110,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
+		if isLogicalOperator(node.Operator) {
+			return evalLogicalExpression(node, env)
+		}
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
@@ -48,6 +126,23 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalBlockStatement(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.ConditionalExpression:
+		condition := Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if isThruty(condition) {
+			return Eval(node.Consequence, env)
+		}
+		return Eval(node.Alternative, env)
+	case *ast.WhileExpression:
+		return evalWhileExpression(node, env)
+	case *ast.ForExpression:
+		return evalForExpression(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
 		if isError(val) {
@@ -59,9 +154,32 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
+		if fn, ok := val.(*object.Function); ok && fn.Name == "" {
+			fn.Name = node.Name.Value
+		}
 		env.Set(node.Name.Value, val)
+	case *ast.StructStatement:
+		if object.IsBuiltinType(node.Name.Value) {
+			return newError("cannot name a struct %s, it is a builtin type", node.Name.Value)
+		}
+		def := &object.StructType{Name: node.Name.Value}
+		for _, field := range node.Fields {
+			def.Fields = append(def.Fields, field.Value)
+		}
+		env.Set(node.Name.Value, def)
+	case *ast.ImplStatement:
+		return evalImplStatement(node, env)
+	case *ast.EnumStatement:
+		if object.IsBuiltinType(node.Name.Value) {
+			return newError("cannot name an enum %s, it is a builtin type", node.Name.Value)
+		}
+		env.Set(node.Name.Value, newEnumType(node))
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
 	case *ast.FunctionLiteral:
 		return &object.Function{
 			Parameters: node.Parameters,
@@ -77,27 +195,46 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		return applyFunction(function, args, node)
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
 		return &object.Array{Elements: elements}
+	case *ast.TupleLiteral:
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Tuple{Elements: elements}
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
+		if node.Optional && left == NULL {
+			return NULL
+		}
 		index := Eval(node.Index, env)
 		if isError(index) {
 			return index
 		}
 		return evalIndexExpression(left, index)
+	case *ast.DotExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		return evalDotExpression(node, left)
 	case *ast.MapLiteral:
 		return evalMapLiteral(node, env)
+	case *ast.SetLiteral:
+		return evalSetLiteral(node, env)
 	}
 
 	return nil
@@ -127,13 +264,20 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 		result = Eval(stmt, env)
 
 		if result != nil {
-			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ,
+				object.BREAK_OBJ, object.CONTINUE_OBJ:
 				return result
 			}
 		}
 	}
 
+	// An empty block, or one ending in a statement without a value like
+	// def, evaluates to null.
+	if result == nil {
+		return NULL
+	}
+
 	return result
 }
 
@@ -172,7 +316,18 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 	switch right.Type() {
 	case object.INTEGER_OBJ:
 		value := right.(*object.Integer).Value
+		if value == math.MinInt64 {
+			if CheckedArithmetic {
+				return newError("integer overflow: -(%d)", value)
+			}
+			return &object.BigInt{Value: new(big.Int).Neg(big.NewInt(value))}
+		}
 		return &object.Integer{Value: -value}
+	case object.BIGINT_OBJ:
+		value := right.(*object.BigInt).Value
+		return integerObject(new(big.Int).Neg(value))
+	case object.DECIMAL_OBJ:
+		return right.(*object.Decimal).Neg()
 	case object.FLOAT_OBJ:
 		value := right.(*object.Float).Value
 		return &object.Float{Value: -value}
@@ -183,6 +338,21 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
+	case operator == "is", operator == "is not":
+		// is checks for null only, other values are compared with ==.
+		if left != NULL && right != NULL {
+			return newError("`%s` only checks for null, use == to compare %s and %s",
+				operator, left.Type(), right.Type())
+		}
+		return nativeBooltoBooleanObject((left == right) == (operator == "is"))
+	case operator == "in":
+		return evalInExpression(left, right)
+	case operator == "not in":
+		result := evalInExpression(left, right)
+		if isError(result) {
+			return result
+		}
+		return nativeBooltoBooleanObject(result == FALSE)
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
@@ -191,10 +361,22 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.BOOLEAN_OBJ && right.Type() == object.BOOLEAN_OBJ:
 		return evalBooleanInfixExpression(operator, left, right)
+	case isInteger(left) && isInteger(right):
+		return evalBigIntInfixExpression(operator, left, right)
+	case isDecimalOperand(left) && isDecimalOperand(right):
+		return evalDecimalInfixExpression(operator, left, right)
+	case isFloatOperand(left) && isFloatOperand(right):
+		return evalFloatInfixExpression(operator, left, right)
+	case left.Type() == object.ARRAY_OBJ && right.Type() == object.ARRAY_OBJ,
+		left.Type() == object.TUPLE_OBJ && right.Type() == object.TUPLE_OBJ,
+		left.Type() == object.MAP_OBJ && right.Type() == object.MAP_OBJ:
+		return evalComparison(operator, left, right)
+	case left.Type() == object.SET_OBJ && right.Type() == object.SET_OBJ:
+		return evalSetInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBooltoBooleanObject(left == right)
+		return nativeBooltoBooleanObject(object.Equal(left, right))
 	case operator == "!=":
-		return nativeBooltoBooleanObject(left != right)
+		return nativeBooltoBooleanObject(!object.Equal(left, right))
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s",
 			left.Type(), operator, right.Type())
@@ -210,13 +392,31 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		value, ok := addInt(leftVal, rightVal)
+		return integerResult(value, ok, leftVal, operator, rightVal)
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		value, ok := subInt(leftVal, rightVal)
+		return integerResult(value, ok, leftVal, operator, rightVal)
 	case "*":
-		return &object.Integer{Value: leftVal * rightVal}
+		value, ok := mulInt(leftVal, rightVal)
+		return integerResult(value, ok, leftVal, operator, rightVal)
 	case "/":
-		return &object.Integer{Value: leftVal / rightVal}
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		value, ok := divInt(leftVal, rightVal)
+		return integerResult(value, ok, leftVal, operator, rightVal)
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero")
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "**":
+		if rightVal < 0 {
+			return &object.Float{Value: math.Pow(float64(leftVal), float64(rightVal))}
+		}
+		value, ok := intPow(leftVal, rightVal)
+		return integerResult(value, ok, leftVal, operator, rightVal)
 	case "<":
 		return nativeBooltoBooleanObject(leftVal < rightVal)
 	case "<=":
@@ -233,15 +433,28 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 		return &object.Integer{Value: leftVal & rightVal}
 	case "|":
 		return &object.Integer{Value: leftVal | rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+	case "<<", ">>":
+		if rightVal < 0 {
+			return newError("negative shift count: %d", rightVal)
+		}
+		if operator == "<<" {
+			value, ok := shlInt(leftVal, rightVal)
+			return integerResult(value, ok, leftVal, operator, rightVal)
+		}
+		return &object.Integer{Value: leftVal >> uint64(rightVal)}
 	default:
 		return newError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
 	}
 }
 
+// evalFloatInfixExpression evaluates an operation on two FLOATs or on a
+// FLOAT and an integer, which is converted to a FLOAT first.
 func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
-	leftVal := left.(*object.Float).Value
-	rightVal := right.(*object.Float).Value
+	leftVal := floatValue(left)
+	rightVal := floatValue(right)
 
 	switch operator {
 	case "+":
@@ -251,7 +464,17 @@ func evalFloatInfixExpression(operator string, left, right object.Object) object
 	case "*":
 		return &object.Float{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
 		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero")
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
 	case "<":
 		return nativeBooltoBooleanObject(leftVal < rightVal)
 	case "<=":
@@ -261,15 +484,47 @@ func evalFloatInfixExpression(operator string, left, right object.Object) object
 	case ">=":
 		return nativeBooltoBooleanObject(leftVal >= rightVal)
 	case "==":
-		return nativeBooltoBooleanObject(floatNearlyEqual(leftVal, rightVal))
+		return nativeBooltoBooleanObject(object.FloatNearlyEqual(leftVal, rightVal))
 	case "!=":
-		return nativeBooltoBooleanObject(!floatNearlyEqual(leftVal, rightVal))
+		return nativeBooltoBooleanObject(!object.FloatNearlyEqual(leftVal, rightVal))
 	default:
 		return newError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
 	}
 }
 
+func isLogicalOperator(operator string) bool {
+	return operator == "&&" || operator == "||" || operator == "??"
+}
+
+// evalLogicalExpression evaluates &&, || and ??. The right side is only
+// evaluated when the left one doesn't decide the result, and the result
+// is the value of the side that decided it: "a" || "b" is "a",
+// null && f() is null without calling f, and a ?? b is a unless a is null.
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	switch node.Operator {
+	case "&&":
+		if !isThruty(left) {
+			return left
+		}
+	case "||":
+		if isThruty(left) {
+			return left
+		}
+	case "??":
+		if left != NULL {
+			return left
+		}
+	}
+
+	return Eval(node.Right, env)
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 
@@ -286,6 +541,116 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	}
 }
 
+func evalWhileExpression(we *ast.WhileExpression, env *object.Environment) object.Object {
+	for {
+		condition := Eval(we.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isThruty(condition) {
+			return NULL
+		}
+
+		result := Eval(we.Body, env)
+		if done, value := loopResult(result); done {
+			return value
+		}
+	}
+}
+
+func evalForExpression(fe *ast.ForExpression, env *object.Environment) object.Object {
+	iterable := Eval(fe.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	keys, values, err := iterationItems(iterable)
+	if err != nil {
+		return err
+	}
+
+	// With a single variable, maps give their keys and the other
+	// iterables their elements.
+	if _, ok := iterable.(*object.Map); ok && fe.Key == nil {
+		values = keys
+	}
+
+	for i := range values {
+		// Every iteration gets its own scope, so closures created in the
+		// body keep the values of that iteration.
+		loopEnv := object.NewEnclosedEnvironment(env)
+		if fe.Key != nil {
+			loopEnv.Set(fe.Key.Value, keys[i])
+		}
+		loopEnv.Set(fe.Value.Value, values[i])
+
+		result := Eval(fe.Body, loopEnv)
+		if done, value := loopResult(result); done {
+			return value
+		}
+	}
+
+	return NULL
+}
+
+// loopResult tells whether the result of a loop body ends the loop and
+// what the loop then evaluates to.
+func loopResult(result object.Object) (bool, object.Object) {
+	if result == nil {
+		return false, nil
+	}
+
+	switch result.Type() {
+	case object.BREAK_OBJ:
+		return true, NULL
+	case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+		return true, result
+	}
+
+	return false, nil
+}
+
+// iterationItems returns what a for loop goes through: the indexes and
+// elements of an array, the indexes and characters of a string, or the
+// keys and values of a map.
+func iterationItems(iterable object.Object) ([]object.Object, []object.Object, object.Object) {
+	var keys, values []object.Object
+
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		for i, el := range iterable.Elements {
+			keys = append(keys, &object.Integer{Value: int64(i)})
+			values = append(values, el)
+		}
+	case *object.String:
+		i := 0
+		for _, ch := range iterable.Value {
+			keys = append(keys, &object.Integer{Value: int64(i)})
+			values = append(values, &object.String{Value: string(ch)})
+			i++
+		}
+	case *object.Map:
+		for _, pair := range iterable.Pairs() {
+			keys = append(keys, pair.Key)
+			values = append(values, pair.Value)
+		}
+	case *object.Tuple:
+		for i, el := range iterable.Elements {
+			keys = append(keys, &object.Integer{Value: int64(i)})
+			values = append(values, el)
+		}
+	case *object.Set:
+		for i, el := range iterable.Elements() {
+			keys = append(keys, &object.Integer{Value: int64(i)})
+			values = append(values, el)
+		}
+	default:
+		return nil, nil, newError("cannot iterate over %s", iterable.Type())
+	}
+
+	return keys, values, nil
+}
+
 // a obj that is not null or false
 func isThruty(obj object.Object) bool {
 	switch obj {
@@ -323,6 +688,116 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 	return newError("identifier not found: " + node.Value)
 }
 
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	// The operator of a compound assignment, "+" for "+=".
+	operator := strings.TrimSuffix(node.Operator, "=")
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		value := Eval(node.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		if operator != "" {
+			current, ok := env.Get(target.Value)
+			if !ok {
+				return newError("identifier not found: " + target.Value)
+			}
+			value = evalInfixExpression(operator, current, value)
+			if isError(value) {
+				return value
+			}
+		}
+
+		if _, ok := env.Assign(target.Value, value); !ok {
+			return newError("identifier not found: " + target.Value)
+		}
+		return value
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+		value := Eval(node.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		if operator != "" {
+			current := evalIndexExpression(left, index)
+			if isError(current) {
+				return current
+			}
+			value = evalInfixExpression(operator, current, value)
+			if isError(value) {
+				return value
+			}
+		}
+
+		return evalIndexAssignment(left, index, value)
+	case *ast.DotExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		value := Eval(node.Value, env)
+		if isError(value) {
+			return value
+		}
+
+		if operator != "" {
+			current := evalDotExpression(target, left)
+			if isError(current) {
+				return current
+			}
+			value = evalInfixExpression(operator, current, value)
+			if isError(value) {
+				return value
+			}
+		}
+
+		return evalFieldAssignment(left, target.Field.Value, value)
+	default:
+		return newError("cannot assign to %s", node.Target.String())
+	}
+}
+
+func evalIndexAssignment(left, index, value object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		if left.Frozen {
+			return newError("cannot modify frozen ARRAY")
+		}
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(left.Elements)) {
+			return newError("index out of range: %d (length %d)",
+				idx.Value, len(left.Elements))
+		}
+		left.Elements[idx.Value] = value
+		return value
+	case *object.Map:
+		if left.Frozen {
+			return newError("cannot modify frozen MAP")
+		}
+		key, ok := object.AsHashable(index)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		left.Set(key, value)
+		return value
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+}
+
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
 
@@ -337,14 +812,55 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+func applyFunction(fn object.Object, args []object.Object, call ast.Node) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			name := fn.Name
+			if name == "" {
+				name = "function"
+			}
+			return newError("wrong number of arguments to `%s`. got=%d, want=%d",
+				name, len(args), len(fn.Parameters))
+		}
+		if len(calls) >= MaxCallDepth {
+			return newError("maximum call depth of %d exceeded", MaxCallDepth)
+		}
+
 		extendedEnv := extendFunctionEnv(fn, args)
+		frame := object.Frame{Function: fn.Name, Pos: call.Pos()}
+		calls = append(calls, frame)
 		evaluated := Eval(fn.Body, extendedEnv)
+		calls = calls[:len(calls)-1]
+		if err, ok := evaluated.(*object.Error); ok {
+			err.Stack = append(err.Stack, frame)
+		}
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
 		return fn.Fn(args...)
+	case *object.BoundMethod:
+		// Count the arguments without self.
+		if method, ok := fn.Method.(*object.Function); ok && len(args)+1 != len(method.Parameters) {
+			return newError("wrong number of arguments to `%s`. got=%d, want=%d",
+				method.Name, len(args), len(method.Parameters)-1)
+		}
+		return applyFunction(fn.Method, append([]object.Object{fn.Receiver}, args...), call)
+	case *object.StructType:
+		if len(args) != len(fn.Fields) {
+			return newError("wrong number of arguments to `%s`. got=%d, want=%d",
+				fn.Name, len(args), len(fn.Fields))
+		}
+		values := make([]object.Object, len(args))
+		copy(values, args)
+		return &object.Struct{Def: fn, Values: values}
+	case *object.EnumVariant:
+		if len(args) != len(fn.Fields) {
+			return newError("wrong number of arguments to `%s.%s`. got=%d, want=%d",
+				fn.Enum.Name, fn.Name, len(args), len(fn.Fields))
+		}
+		values := make([]object.Object, len(args))
+		copy(values, args)
+		return &object.EnumValue{Variant: fn, Values: values}
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
@@ -366,20 +882,68 @@ func unwrapReturnValue(obj object.Object) object.Object {
 }
 
 func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
-	if operator != "+" {
-		return newError("unknown operator: %s %s %s",
-			left.Type(), operator, right.Type())
+	if operator == "+" {
+		leftVal := left.(*object.String).Value
+		rightVal := right.(*object.String).Value
+		return &object.String{Value: leftVal + rightVal}
 	}
+	return evalComparison(operator, left, right)
+}
 
-	leftVal := left.(*object.String).Value
-	rightVal := right.(*object.String).Value
-	return &object.String{Value: leftVal + rightVal}
+// evalComparison evaluates ==, !=, <, <=, > and >= with object.Equal and
+// object.Compare. Strings are ordered byte by byte, arrays element by
+// element, maps can only be checked for equality.
+func evalComparison(operator string, left, right object.Object) object.Object {
+	switch operator {
+	case "==":
+		return nativeBooltoBooleanObject(object.Equal(left, right))
+	case "!=":
+		return nativeBooltoBooleanObject(!object.Equal(left, right))
+	case "<", "<=", ">", ">=":
+		c, ok := object.Compare(left, right)
+		if !ok {
+			if left.Type() == right.Type() && left.Type() != object.ARRAY_OBJ &&
+				left.Type() != object.TUPLE_OBJ {
+				break
+			}
+			return newError("cannot compare %s %s %s",
+				left.Inspect(), operator, right.Inspect())
+		}
+		switch operator {
+		case "<":
+			return nativeBooltoBooleanObject(c < 0)
+		case "<=":
+			return nativeBooltoBooleanObject(c <= 0)
+		case ">":
+			return nativeBooltoBooleanObject(c > 0)
+		default:
+			return nativeBooltoBooleanObject(c >= 0)
+		}
+	}
+	return newError("unknown operator: %s %s %s",
+		left.Type(), operator, right.Type())
+}
+
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		value := Eval(part, env)
+		if isError(value) {
+			return value
+		}
+		out.WriteString(value.Inspect())
+	}
+
+	return &object.String{Value: out.String()}
 }
 
 func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
-		return evalArrayIndexExpression(left, index)
+		return evalArrayIndexExpression(left.(*object.Array).Elements, index)
+	case left.Type() == object.TUPLE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(left.(*object.Tuple).Elements, index)
 	case left.Type() == object.MAP_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
@@ -387,78 +951,249 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	}
 }
 
-func evalArrayIndexExpression(array, index object.Object) object.Object {
-	arrayObj := array.(*object.Array)
+// evalDotExpression reads a field of a struct or an enum value, a variant
+// of an enum or a string key of a map. Without such a field or key,
+// a.name is the method name of a, bound to a so that calling it passes a
+// as self.
+func evalDotExpression(node *ast.DotExpression, left object.Object) object.Object {
+	if node.Optional && left == NULL {
+		return NULL
+	}
+
+	name := node.Field.Value
+	switch left := left.(type) {
+	case *object.Map:
+		if value, ok := left.Get(&object.String{Value: name}); ok {
+			return value
+		}
+	case *object.Struct:
+		if value, ok := left.Get(name); ok {
+			return value
+		}
+	case *object.EnumValue:
+		if value, ok := left.Get(name); ok {
+			return value
+		}
+	case *object.EnumType:
+		variant, ok := left.Variant(name)
+		if !ok {
+			return newError("%s has no variant %s", left.Name, name)
+		}
+		if variant.Unit != nil {
+			return variant.Unit
+		}
+		return variant
+	}
+
+	if method, ok := lookupMethod(left, name); ok {
+		return &object.BoundMethod{Receiver: left, Name: name, Method: method}
+	}
+
+	switch left := left.(type) {
+	case *object.Map:
+		return NULL
+	case *object.Struct:
+		return newError("%s has no field %s", left.Def.Name, name)
+	case *object.EnumValue:
+		return newError("%s has no field %s", left.Inspect(), name)
+	default:
+		return newError("cannot access field %s of %s", name, left.Type())
+	}
+}
+
+// evalImplStatement adds the methods of an impl block to a struct type.
+func evalImplStatement(node *ast.ImplStatement, env *object.Environment) object.Object {
+	val := evalIdentifier(node.Name, env)
+	if isError(val) {
+		return val
+	}
+	def, ok := val.(*object.StructType)
+	if !ok {
+		return newError("impl needs a struct, got %s", val.Type())
+	}
+
+	if def.Methods == nil {
+		def.Methods = make(map[string]object.Object)
+	}
+	for _, m := range node.Methods {
+		method := Eval(m.Value, env)
+		if isError(method) {
+			return method
+		}
+		fn, ok := method.(*object.Function)
+		if !ok {
+			return newError("method %s of %s must be a function, got %s",
+				m.Name.Value, def.Name, method.Type())
+		}
+		if len(fn.Parameters) == 0 {
+			return newError("method %s of %s needs a self parameter",
+				m.Name.Value, def.Name)
+		}
+		if fn.Name == "" {
+			fn.Name = def.Name + "." + m.Name.Value
+		}
+		def.Methods[m.Name.Value] = fn
+	}
+
+	return nil
+}
+
+// evalFieldAssignment sets a field of a struct, or the string key with the
+// name of the field of a map.
+func evalFieldAssignment(left object.Object, field string, value object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Map:
+		return evalIndexAssignment(left, &object.String{Value: field}, value)
+	case *object.Struct:
+		if left.Frozen {
+			return newError("cannot modify frozen %s", left.Def.Name)
+		}
+		if !left.Set(field, value) {
+			return newError("%s has no field %s", left.Def.Name, field)
+		}
+		return value
+	default:
+		return newError("cannot assign to field %s of %s", field, left.Type())
+	}
+}
+
+func evalArrayIndexExpression(elements []object.Object, index object.Object) object.Object {
 	idx := index.(*object.Integer).Value
-	max := int64(len(arrayObj.Elements) - 1)
+	max := int64(len(elements) - 1)
 
 	if idx < 0 || idx > max {
 		return NULL
 	}
 
-	return arrayObj.Elements[idx]
+	return elements[idx]
 }
 
 func evalMapLiteral(node *ast.MapLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	mapObj := object.NewMap()
 
-	for keyNode, valueNode := range node.Pairs {
+	for _, keyNode := range node.Keys {
 		key := Eval(keyNode, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.AsHashable(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(node.Pairs[keyNode], env)
 		if isError(value) {
 			return value
 		}
 
-		pairs[hashKey.HashKey()] = object.HashPair{
-			Key:   key,
-			Value: value,
-		}
+		mapObj.Set(hashKey, value)
 	}
 
-	return &object.Map{Pairs: pairs}
+	return mapObj
 }
 
 func evalHashIndexExpression(left, index object.Object) object.Object {
 	mapObj := left.(*object.Map)
 
-	key, ok := index.(object.Hashable)
+	key, ok := object.AsHashable(index)
 	if !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := mapObj.Pairs[key.HashKey()]
+	value, ok := mapObj.Get(key)
 	if !ok {
 		return NULL
 	}
 
-	return pair.Value
+	return value
 }
 
-func floatNearlyEqual(f1, f2 float64) bool {
-	const epsilon = 0.0001
-	const min_float = math.SmallestNonzeroFloat64
-	const max_float = math.MaxFloat64
+func evalSetLiteral(node *ast.SetLiteral, env *object.Environment) object.Object {
+	elements := evalExpressions(node.Elements, env)
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
+	}
+
+	set := object.NewSet()
+	for _, el := range elements {
+		hashable, ok := object.AsHashable(el)
+		if !ok {
+			return newError("unusable as set element: %s", el.Type())
+		}
+		set.Add(hashable)
+	}
 
-	absf1 := math.Abs(f1)
-	absf2 := math.Abs(f2)
-	diff := math.Abs(f1 - f2)
+	return set
+}
 
-	if f1 == f2 {
-		return true
-	} else if f1 == 0 || f2 == 0 || absf1+absf2 < min_float {
-		return diff < (epsilon * min_float)
-	} else {
-		return diff/math.Min(absf1+absf2, max_float) < epsilon
+// evalSetInfixExpression evaluates the set operators: | is the union, &
+// the intersection, - the difference and ^ the elements in only one of
+// the sets. <= and < tell whether the left set is a subset of the right
+// one, >= and > whether it is a superset.
+func evalSetInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := left.(*object.Set)
+	rightVal := right.(*object.Set)
+
+	switch operator {
+	case "|":
+		return leftVal.Union(rightVal)
+	case "&":
+		return leftVal.Intersection(rightVal)
+	case "-":
+		return leftVal.Difference(rightVal)
+	case "^":
+		return leftVal.Difference(rightVal).Union(rightVal.Difference(leftVal))
+	case "<=":
+		return nativeBooltoBooleanObject(leftVal.IsSubset(rightVal))
+	case "<":
+		return nativeBooltoBooleanObject(leftVal.IsSubset(rightVal) &&
+			leftVal.Len() < rightVal.Len())
+	case ">=":
+		return nativeBooltoBooleanObject(rightVal.IsSubset(leftVal))
+	case ">":
+		return nativeBooltoBooleanObject(rightVal.IsSubset(leftVal) &&
+			leftVal.Len() > rightVal.Len())
+	}
+	return evalComparison(operator, left, right)
+}
+
+// evalInExpression tells whether left is an element of a set, array or
+// tuple, a key of a map or a substring of a string.
+func evalInExpression(left, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Set:
+		key, ok := object.AsHashable(left)
+		return nativeBooltoBooleanObject(ok && right.Has(key))
+	case *object.Map:
+		key, ok := object.AsHashable(left)
+		if !ok {
+			return FALSE
+		}
+		_, found := right.Get(key)
+		return nativeBooltoBooleanObject(found)
+	case *object.Array:
+		return containsEqual(right.Elements, left)
+	case *object.Tuple:
+		return containsEqual(right.Elements, left)
+	case *object.String:
+		str, ok := left.(*object.String)
+		if !ok {
+			return newError("substring must be STRING, got %s", left.Type())
+		}
+		return nativeBooltoBooleanObject(strings.Contains(right.Value, str.Value))
+	default:
+		return newError("`in` not supported: %s", right.Type())
+	}
+}
+
+func containsEqual(elements []object.Object, obj object.Object) object.Object {
+	for _, el := range elements {
+		if object.Equal(obj, el) {
+			return TRUE
+		}
 	}
+	return FALSE
 }
 
 func evalBooleanInfixExpression(operator string, left, right object.Object) object.Object {
@@ -470,10 +1205,6 @@ func evalBooleanInfixExpression(operator string, left, right object.Object) obje
 		return nativeBooltoBooleanObject(leftVal == rightVal)
 	case "!=":
 		return nativeBooltoBooleanObject(leftVal != rightVal)
-	case "&&":
-		return nativeBooltoBooleanObject(leftVal && rightVal)
-	case "||":
-		return nativeBooltoBooleanObject(leftVal || rightVal)
 	default:
 		return newError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"math"
 	"testing"
 
 	"github.com/batt0s/rizzy/lexer"
@@ -43,6 +44,8 @@ func TestEvalFloatExpression(t *testing.T) {
 	}{
 		{"5.1", 5.1},
 		{"10.", 10.0},
+		{".5", 0.5},
+		{"-.25 + 1", 0.75},
 		{"-5.2", -5.2},
 		{"-10.1", -10.1},
 		{"5.1 + 5.1 + 5.2 + 5.2 - 10.6", 10.0},
@@ -111,7 +114,7 @@ func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
 		return false
 	}
 
-	if !floatNearlyEqual(result.Value, expected) {
+	if !object.FloatNearlyEqual(result.Value, expected) {
 		t.Errorf("object has wrong value. got=%f, want=%f",
 			result.Value, expected)
 		return false
@@ -200,6 +203,18 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 > 2) { 10 }", nil},
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (1 > 2) { 10 } else if (2 > 1) { 20 } else { 30 }", 20},
+		{"if (1 > 2) { 10 } else if (2 > 3) { 20 } else { 30 }", 30},
+		{"if (1 > 2) { 10 } else if (2 > 3) { 20 }", nil},
+		{"if (1 > 2) { 10 } else if (2 > 3) { 20 } else if (true) { 40 }", 40},
+		{"true ? 10 : 20", 10},
+		{"null ? 10 : 20", 20},
+		{"1 > 2 ? 10 : 2 > 1 ? 20 : 30", 20},
+		{"def x = 0; true ? 10 : x = 5; x", 0},
+		{"def x = 0; false ? 10 : x = 5; x", 5},
+		{"false ? 10 : null", nil},
+		{"def c = false; c ?.5 : 2", 2},
+		{"def c = true; (c ?[10] : [20])[0]", 10},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -461,8 +476,8 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`float("1")`, 1.0},
 		{`float(1.1)`, 1.1},
 		{`float("1.1")`, 1.1},
-		{`float(true)`, "argument to `float` must be INTEGER, FLOAT or STRING, got BOOLEAN"},
-		{`float(false)`, "argument to `float` must be INTEGER, FLOAT or STRING, got BOOLEAN"},
+		{`float(true)`, "argument to `float` must be INTEGER, BIGINT, FLOAT, DECIMAL or STRING, got BOOLEAN"},
+		{`float(false)`, "argument to `float` must be INTEGER, BIGINT, FLOAT, DECIMAL or STRING, got BOOLEAN"},
 		{`range()`, "wrong number of arguments. got=0, want=2 or 3"},
 		{`range(1)`, "wrong number of arguments. got=1, want=2 or 3"},
 		{`range(1,2,3,4)`, "wrong number of arguments. got=4, want=2 or 3"},
@@ -582,23 +597,26 @@ false: 6
 	if !ok {
 		t.Fatalf("Eval didn't return Map. got=%T (%+v)", evaluated, evaluated)
 	}
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
 	}
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", result.Len())
 	}
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
-		if !ok {
-			t.Errorf("no pair for given key in Pairs")
+	for i, pair := range result.Pairs() {
+		if !object.Equal(pair.Key, expected[i].key) {
+			t.Errorf("pair %d has wrong key. got=%s, want=%s",
+				i, pair.Key.Inspect(), expected[i].key.Inspect())
 		}
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, pair.Value, expected[i].value)
 	}
 }
 
@@ -635,7 +653,127 @@ func TestMapIndexExpressions(t *testing.T) {
 			`{false: 5}[false]`,
 			5,
 		},
+		{
+			`{1.5: 5}[1.5]`,
+			5,
+		},
+		{
+			`{2.0: 5}[2]`,
+			5,
+		},
+		{
+			`{2: 5}[2.5]`,
+			nil,
+		},
+		{
+			`{2d: 5}[2.0]`,
+			5,
+		},
+		{
+			`{2.0: 5}[2.00d]`,
+			5,
+		},
+		{
+			`{2n: 5}[2d]`,
+			5,
+		},
+		{
+			`{0.5d: 5}[0.5]`,
+			5,
+		},
+		{
+			`{0.1d: 5}[0.1]`,
+			nil,
+		},
+		{
+			`{null: 5}[null]`,
+			5,
+		},
+		{
+			`{1: 5, 1.0: 6}[1]`,
+			6,
+		},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestErrorTraceback(t *testing.T) {
+	input := `def add = func(a, b) {
+  a + b
+};
+def twice = func(x) { add(x, x) };
+twice(true);`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Message != "unknown operator: BOOLEAN + BOOLEAN" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+	if errObj.Pos.String() != "2:3" || errObj.End.String() != "2:8" {
+		t.Errorf("wrong error span. got=%s-%s", errObj.Pos, errObj.End)
+	}
+
+	expected := []struct {
+		function string
+		pos      string
+	}{
+		{"add", "4:23"},
+		{"twice", "5:1"},
+	}
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong stack length. want=%d, got=%d (%+v)",
+			len(expected), len(errObj.Stack), errObj.Stack)
 	}
+	for i, tt := range expected {
+		frame := errObj.Stack[i]
+		if frame.Function != tt.function || frame.Pos.String() != tt.pos {
+			t.Errorf("stack[%d] wrong. want=%s at %s, got=%s at %s",
+				i, tt.function, tt.pos, frame.Function, frame.Pos)
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`def name = "Rizzler"; "hello ${name}"`, "hello Rizzler"},
+		{`def items = [1, 2]; "you have ${len(items)} items: ${items}"`, "you have 2 items: [1, 2]"},
+		{`"${1 + 1} is ${true}, nested: ${"<${"x"}>"}"`, "2 is true, nested: <x>"},
+		{`"cost: \${price}"`, "cost: ${price}"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestWhileExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"def i = 0; while (i < 5) { def i = i + 1; }; i", 5},
+		{"def i = 0; while (i < 100000) { def i = i + 1; }; i", 100000},
+		{"def i = 0; while (true) { def i = i + 1; if (i == 3) { break } }; i", 3},
+		{"def i = 0; def n = 0; while (i < 5) { def i = i + 1; if (i == 2) { continue }; def n = n + i; }; n", 13},
+		{"while (false) { 1 }", nil},
+		{"def f = func() { while (true) { return 7; } }; f()", 7},
+	}
+
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
 		integer, ok := tt.expected.(int)
@@ -646,3 +784,1050 @@ func TestMapIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestForExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`def find = func(arr, x) { for (el in arr) { if (el == x) { return el * 10; } }; -1 }; find([1, 2, 3], 2)`, 20},
+		{`def find = func(arr, x) { for (i, el in arr) { if (el == x) { return i; } }; -1 }; find([1, 2, 3], 3)`, 2},
+		{`def find = func(arr, x) { for (i, el in arr) { if (el == x) { return i; } }; -1 }; find([1, 2, 3], 4)`, -1},
+		{`def f = func() { for (x in range(1, 100000)) { if (x == 99999) { return x; } } }; f()`, 99999},
+		{`def f = func() { for (ch in "héllo") { return ch; } }; f()`, "h"},
+		{`def f = func() { for (i, ch in "héllo") { if (i == 1) { return ch; } } }; f()`, "é"},
+		{`def f = func() { for (k in {"a": 1}) { return k; } }; f()`, "a"},
+		{`def f = func() { for (k, v in {"a": 1}) { return v; } }; f()`, 1},
+		{`def f = func() { for (x in [1, 2, 3]) { if (x < 3) { continue }; return x; } }; f()`, 3},
+		{`for (x in [1, 2, 3]) { if (x == 2) { break } }`, nil},
+		{`for (x in []) { x }`, nil},
+		{`for (x in 5) { x }`, "cannot iterate over INTEGER"},
+		{`for (x in [1]) { x + true }`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q",
+						expected, errObj.Message)
+				}
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"def x = 1; x = 2; x", 2},
+		{"def x = 1; x = x + 1", 2},
+		{"def a = 1; def b = 2; a = b = 5; a + b", 10},
+		{"def x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", 6},
+		{"def s = \"a\"; s += \"b\"; s", "ab"},
+		{"def counter = func() { def n = 0; func() { n += 1 } }; def c = counter(); c(); c(); c()", 3},
+		{"def total = 0; for (x in [1, 2, 3]) { total += x }; total", 6},
+		{"def arr = [1, 2, 3]; arr[1] = 20; arr[1]", 20},
+		{"def arr = [1, 2, 3]; arr[2] += 10; arr[2]", 13},
+		{"def m = {\"a\": 1}; m[\"b\"] = 2; m[\"a\"] + m[\"b\"]", 3},
+		{"def m = {\"a\": 1}; m[\"a\"] *= 7; m[\"a\"]", 7},
+		{"y = 1", "identifier not found: y"},
+		{"y += 1", "identifier not found: y"},
+		{"len = 1", "identifier not found: len"},
+		{"def arr = [1]; arr[1] = 2", "index out of range: 1 (length 1)"},
+		{"def arr = [1]; arr[\"a\"] = 2", "array index must be INTEGER, got STRING"},
+		{"def m = {}; m[[1]] = 2", "unusable as hash key: ARRAY"},
+		{"def s = \"abc\"; s[0] = \"x\"", "index assignment not supported: STRING"},
+		{"def x = 1; x += true", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q",
+						expected, errObj.Message)
+				}
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestArithmeticOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"7 % 3", 7 % 3},
+		{"-7 % 3", -7 % 3},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"(-2) ** 3", -8},
+		{"3 ** 0", 1},
+		{"3 ** 39", 4052555153018976267},
+		{"2 ** -1", 0.5},
+		{"0b1100 ^ 0b1010", 6},
+		{"1 << 10", 1024},
+		{"-16 >> 2", -4},
+		{"0xFF & 0x0F | 0x100", 0x10F},
+		{"1 << -1", "negative shift count: -1"},
+		{"7.5 % 2.", 1.5},
+		{"2. ** 0.5", 1.4142135623730951},
+		{"1.5 ^ 1.5", "unknown operator: FLOAT ^ FLOAT"},
+		{"pow(3, 39)", 4052555153018976267},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestArithmeticErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1 / 0", "division by zero"},
+		{"1 % 0", "modulo by zero"},
+		{"1. / 0.", "division by zero"},
+		{"1. % 0.", "modulo by zero"},
+		{"def x = 5; x /= 0", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	CheckedArithmetic = true
+	defer func() { CheckedArithmetic = false }()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"9223372036854775807 + 1", "integer overflow: 9223372036854775807 + 1"},
+		{"-9223372036854775807 - 2", "integer overflow: -9223372036854775807 - 2"},
+		{"4611686018427387904 * 2", "integer overflow: 4611686018427387904 * 2"},
+		{"2 ** 63", "integer overflow: 2 ** 63"},
+		{"1 << 63", "integer overflow: 1 << 63"},
+		{"pow(10, 19)", "integer overflow: 10 ** 19"},
+		{"def m = -9223372036854775807 - 1; -m", "integer overflow: -(-9223372036854775808)"},
+		{"def m = -9223372036854775807 - 1; m / -1", "integer overflow: -9223372036854775808 / -1"},
+		{"2 ** 62", 4611686018427387904},
+		{"-2 ** 63", "integer overflow: 2 ** 63"},
+		{"(-2) ** 63", -9223372036854775808},
+		{"9223372036854775807 - 1", 9223372036854775806},
+		{"1 << 62", 4611686018427387904},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)",
+					tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestRuntimeFailuresBecomeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"def f = func(a, b) { a + b }; f(1)", "wrong number of arguments to `f`. got=1, want=2"},
+		{"func(a) { a }(1, 2)", "wrong number of arguments to `function`. got=2, want=1"},
+		{"def f = func(n) { f(n + 1) }; f(0)", "maximum call depth of 10000 exceeded"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestPanicsBecomeErrors(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("boom", &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		panic("boom")
+	}})
+
+	eval := func(input string) (evaluated object.Object) {
+		defer func() {
+			if r := recover(); r != nil {
+				evaluated = Recovered(r)
+			}
+		}()
+		program := parser.New(lexer.New(input)).ParseProgram()
+		return Eval(program, env)
+	}
+
+	evaluated := eval("def f = func() { 1 + boom() };\ndef g = func() { f() };\ng()")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "internal error: boom" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+	if errObj.Pos.String() != "1:22" || errObj.End.String() != "1:28" {
+		t.Errorf("wrong error span. got=%s-%s", errObj.Pos, errObj.End)
+	}
+	stack := []string{"f 2:18", "g 3:1"}
+	if len(errObj.Stack) != len(stack) {
+		t.Fatalf("wrong stack. got=%+v", errObj.Stack)
+	}
+	for i, frame := range errObj.Stack {
+		if got := frame.Function + " " + frame.Pos.String(); got != stack[i] {
+			t.Errorf("wrong frame %d. want=%q, got=%q", i, stack[i], got)
+		}
+	}
+
+	if evaluated := eval("f"); evaluated.Type() != object.FUNCTION_OBJ {
+		t.Errorf("the environment is gone after a panic. got=%s", evaluated.Inspect())
+	}
+}
+
+func TestBigIntArithmetic(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType object.ObjectType
+		expected     string
+	}{
+		{"9223372036854775807 + 1", object.BIGINT_OBJ, "9223372036854775808"},
+		{"-9223372036854775807 - 2", object.BIGINT_OBJ, "-9223372036854775809"},
+		{"2 ** 64", object.BIGINT_OBJ, "18446744073709551616"},
+		{"1 << 70", object.BIGINT_OBJ, "1180591620717411303424"},
+		{"def m = -9223372036854775807 - 1; -m", object.BIGINT_OBJ, "9223372036854775808"},
+		{"pow(10, 20)", object.BIGINT_OBJ, "100000000000000000000"},
+		{"123n", object.BIGINT_OBJ, "123"},
+		{"123n + 1", object.INTEGER_OBJ, "124"},
+		{"99999999999999999999 * 10", object.BIGINT_OBJ, "999999999999999999990"},
+		{"-7n / 2", object.INTEGER_OBJ, "-3"},
+		{"-7n % 2", object.INTEGER_OBJ, "-1"},
+		{"0xF0n ^ 0xFF", object.INTEGER_OBJ, "15"},
+		{"(1n << 100) >> 99", object.INTEGER_OBJ, "2"},
+		{"2n ** -1", object.FLOAT_OBJ, "0.5"},
+		{"5n == 5", object.BOOLEAN_OBJ, "true"},
+		{"10n > 9223372036854775807", object.BOOLEAN_OBJ, "false"},
+		{"2 ** 64 > 9223372036854775807", object.BOOLEAN_OBJ, "true"},
+		{"int(2 ** 64 / 2 ** 10)", object.INTEGER_OBJ, "18014398509481984"},
+		{"int(5n)", object.INTEGER_OBJ, "5"},
+		{`int("123456789012345678901234567890")`, object.BIGINT_OBJ, "123456789012345678901234567890"},
+		{"int(1e20)", object.BIGINT_OBJ, "100000000000000000000"},
+		{"float(2 ** 64)", object.FLOAT_OBJ, "1.8446744073709552e+19"},
+		{"def m = {5: 1}; m[5n]", object.INTEGER_OBJ, "1"},
+		{"type(1n)", object.STRING_OBJ, "BIGINT"},
+		{"1n / 0", object.ERROR_OBJ, "ERROR: division by zero"},
+		{"1n % 0", object.ERROR_OBJ, "ERROR: modulo by zero"},
+		{"1n << -1", object.ERROR_OBJ, "ERROR: negative shift count: -1"},
+		{"2 ** 100000000", object.ERROR_OBJ, "ERROR: integer too large: 2 ** 100000000"},
+		{"1 ** 100000000000n", object.INTEGER_OBJ, "1"},
+		{"(9223372036854775807 + 1) - 1", object.INTEGER_OBJ, "9223372036854775807"},
+		{"-(2 ** 63)", object.INTEGER_OBJ, "-9223372036854775808"},
+		{"-5n", object.INTEGER_OBJ, "-5"},
+		{"[1, 2][(2 ** 63) - 9223372036854775807]", object.INTEGER_OBJ, "2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() != tt.expectedType || evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s %s, got %s %s", tt.input,
+				tt.expectedType, tt.expected, evaluated.Type(), evaluated.Inspect())
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedType object.ObjectType
+		expected     string
+	}{
+		{"0.1d + 0.2d", object.DECIMAL_OBJ, "0.3"},
+		{"0.1d + 0.2d == 0.3d", object.BOOLEAN_OBJ, "true"},
+		{"1.50d", object.DECIMAL_OBJ, "1.50"},
+		{"1_000.5d - 0.25d", object.DECIMAL_OBJ, "1000.25"},
+		{"1.005d * 3", object.DECIMAL_OBJ, "3.015"},
+		{"2e3d", object.DECIMAL_OBJ, "2000"},
+		{"2.5e-3d", object.DECIMAL_OBJ, "0.0025"},
+		{"1d / 3", object.DECIMAL_OBJ, "0.3333333333333333333333333333"},
+		{"2d / 3", object.DECIMAL_OBJ, "0.6666666666666666666666666667"},
+		{"1.50d / 3", object.DECIMAL_OBJ, "0.50"},
+		{"10d / 4", object.DECIMAL_OBJ, "2.5"},
+		{"-7.5d % 2", object.DECIMAL_OBJ, "-1.5"},
+		{"1.1d ** 2", object.DECIMAL_OBJ, "1.21"},
+		{"2d ** -2", object.DECIMAL_OBJ, "0.25"},
+		{"-1.5d", object.DECIMAL_OBJ, "-1.5"},
+		{"1.0d == 1", object.BOOLEAN_OBJ, "true"},
+		{"0.5d < 1n", object.BOOLEAN_OBJ, "true"},
+		{"def m = {2: 1}; m[2.00d]", object.INTEGER_OBJ, "1"},
+		{"def m = {1.50d: 1}; m[1.5d]", object.INTEGER_OBJ, "1"},
+		{"int(-2.75d)", object.INTEGER_OBJ, "-2"},
+		{"float(2.5d)", object.FLOAT_OBJ, "2.5"},
+		{"decimal(0.1)", object.DECIMAL_OBJ, "0.1"},
+		{`decimal("19.99")`, object.DECIMAL_OBJ, "19.99"},
+		{"decimal(2 ** 64)", object.DECIMAL_OBJ, "18446744073709551616"},
+		{"type(1d)", object.STRING_OBJ, "DECIMAL"},
+		{"1d / 0", object.ERROR_OBJ, "ERROR: division by zero"},
+		{"1d % 0d", object.ERROR_OBJ, "ERROR: modulo by zero"},
+		{"2d ** 0.5d", object.ERROR_OBJ, "ERROR: exponent of a DECIMAL must be an integer, got 0.5"},
+		{"10d ** 100000000", object.ERROR_OBJ, "ERROR: decimal too large: 10 ** 100000000"},
+		{"0.1d ** 10000000000", object.ERROR_OBJ, "ERROR: decimal too large: 0.1 ** 10000000000"},
+		{"0.1d ** -100000000", object.ERROR_OBJ, "ERROR: decimal too large: 0.1 ** -100000000"},
+		{"1d ** 1000000000000000000000000000000", object.DECIMAL_OBJ, "1"},
+		{"(-1d) ** 1000000000000000000000000000001", object.DECIMAL_OBJ, "-1"},
+		{"1.0d ** -1000000000000000000000000000000", object.DECIMAL_OBJ, "1"},
+		{"0d ** 1000000000000000000000000000000", object.DECIMAL_OBJ, "0"},
+		{"1.5d ** 1000000000000000000000000000000", object.ERROR_OBJ,
+			"ERROR: decimal too large: 1.5 ** 1000000000000000000000000000000"},
+		{`decimal("abc")`, object.ERROR_OBJ, "ERROR: error parsing decimal, check given string"},
+		{"setprecision(4); 2d / 3", object.DECIMAL_OBJ, "0.6667"},
+		{`setprecision(3); setrounding("down"); 2d / 3`, object.DECIMAL_OBJ, "0.666"},
+		{`setprecision(3); setrounding("half_up"); 1.125d * 1`, object.DECIMAL_OBJ, "1.13"},
+		{`setprecision(3); 1.125d * 1`, object.DECIMAL_OBJ, "1.12"},
+		{`setprecision(2); 12345.678d + 0`, object.DECIMAL_OBJ, "12346"},
+		{`setrounding("sideways")`, object.ERROR_OBJ,
+			`ERROR: unknown rounding mode "sideways", want half_even, half_up, half_down, up, down, ceiling or floor`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		DecimalContext = object.DefaultDecimalContext
+		if evaluated.Type() != tt.expectedType || evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s %s, got %s %s", tt.input,
+				tt.expectedType, tt.expected, evaluated.Type(), evaluated.Inspect())
+		}
+	}
+}
+
+func TestMixedArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1 + 2.5", 3.5},
+		{"2.5 + 1", 3.5},
+		{"5 - 0.5", 4.5},
+		{"2 * 1.5", 3.0},
+		{"3 / 2.0", 1.5},
+		{"7.5 % 2", 1.5},
+		{"2 ** 0.5", math.Sqrt(2)},
+		{"2.0 ** 3", 8.0},
+		{"2 ** 64 * 0.5", 9223372036854775808.0},
+		{"def x = 1; x += 0.5; x", 1.5},
+		{"3 < 3.5", true},
+		{"3.5 < 3", false},
+		{"3 <= 3.0", true},
+		{"4 > 3.9", true},
+		{"4 >= 4.1", false},
+		{"1 == 1.0", true},
+		{"1.0 != 1", false},
+		{"0.1 + 0.2 == 0.3", true},
+		{"1 == 1.5", false},
+		{"2 ** 64 == 18446744073709551616.0", true},
+		{"1 / 0.0", "division by zero"},
+		{"1 & 2.0", "unknown operator: INTEGER & FLOAT"},
+		{"1.5d + 1.5", "type mismatch: DECIMAL + FLOAT"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)",
+					tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestStructuralComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"a" == "a"`, true},
+		{"[100000.5] < [100000.1]", false},
+		{"[100000.1] < [100000.5]", true},
+		{"[0.1 + 0.2] == [0.3]", true},
+		{`"a" != "a"`, false},
+		{`"a" == "b"`, false},
+		{`"abc" < "abd"`, true},
+		{`"ab" < "abc"`, true},
+		{`"b" <= "a"`, false},
+		{`"b" > "a"`, true},
+		{`"a" >= "a"`, true},
+		{`"Z" < "a"`, true},
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, 2] == [1, 2, 3]", false},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, 2.0] == [1.0, 2]", true},
+		{`[1, "a"] == [1, "b"]`, false},
+		{"[1, 2] < [1, 3]", true},
+		{"[1, 2] < [1, 2, 0]", true},
+		{"[2] > [1, 5]", true},
+		{"[] <= []", true},
+		{`[["a"], 1] < [["b"], 0]`, true},
+		{`{"a": 1, "b": [1, 2]} == {"b": [1, 2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} != {"a": 1, "b": 2}`, true},
+		{`{1: "x"} == {1n: "x"}`, true},
+		{`1 == "1"`, false},
+		{`[1] == "1"`, false},
+		{"def f = func() { 1 }; f == f", true},
+		{"func() { 1 } == func() { 1 }", false},
+		{`[1] < ["a"]`, "cannot compare [1] < [a]"},
+		{`{"a": 1} < {"a": 2}`, "unknown operator: MAP < MAP"},
+		{`"a" - "b"`, "unknown operator: STRING - STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)",
+					tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestSortBuiltin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{"sort([2.5, 1, 3n])", "[1, 2.5, 3]"},
+		{"sort([2.5d, 1, 3n])", "[1, 2.5, 3]"},
+		{`sort(["pear", "apple", "fig"])`, "[apple, fig, pear]"},
+		{"sort([[1, 2], [1], [0, 5]])", "[[0, 5], [1], [1, 2]]"},
+		{"sort([])", "[]"},
+		{"sort([100000.5, 100000.1, 100000.3, 100000.2])", "[100000.1, 100000.2, 100000.3, 100000.5]"},
+		{"def a = [2, 1]; sort(a); a", "[2, 1]"},
+		{`sort([1, "a"])`, "ERROR: cannot compare a and 1 in `sort`"},
+		{"sort(1)", "ERROR: argument to `sort` must be ARRAY, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true && false", false},
+		{"true || false", true},
+		{"1 && 2", 2},
+		{"1 || 2", 1},
+		{`false || "default"`, "default"},
+		{`{}["x"] || 5`, 5},
+		{`{}["x"] && 5`, nil},
+		{"false && 1 / 0", false},
+		{"true || 1 / 0", true},
+		{"def n = 0; def f = func() { n += 1; true }; false && f(); true || f(); n", 0},
+		{"def n = 0; def f = func() { n += 1; true }; true && f(); false || f(); n", 2},
+		{`def m = {}; m["k"] != 1 && m["k"]["j"]`, "index operator not supported: NULL"},
+		{`{}["x"] ?? 3`, 3},
+		{"false ?? 3", false},
+		{"0 ?? 3", 0},
+		{"1 ?? 1 / 0", 1},
+		{`{}["a"] ?? {}["b"] ?? "c"`, "c"},
+		{"1 / 0 || true", "division by zero"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("%q: expected %q, got %q", tt.input, expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q",
+						expected, obj.Message)
+				}
+			default:
+				t.Errorf("%q: unexpected result %T(%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestOptionalChaining(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`def m = {"a": {"b": 1}}; m?.a?.b`, 1},
+		{`def m = {"a": {"b": 1}}; m?.x?.b`, nil},
+		{`def m = {"a": [1, 2]}; m?.a?[1]`, 2},
+		{`def m = {"a": [1, 2]}; m?.x?[1]`, nil},
+		{`def m = {"a": {"b": 1}}; m?["a"]?["b"]`, 1},
+		{`def m = {}; m?.x?[1 / 0]`, nil},
+		{`def m = {"a": 1}; m?.a ?? 5`, 1},
+		{`def m = {}; m?.a ?? 5`, 5},
+		{`def m = {"a": 1}; m?.a?.b`, "cannot access field b of INTEGER"},
+		{`def m = {}; m?.x[0]`, "index operator not supported: NULL"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)",
+					tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestNullHandling(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"null", nil},
+		{"null == null", true},
+		{"null != null", false},
+		{"1 == null", false},
+		{"null != 1", true},
+		{"false == null", false},
+		{`def m = {}; m["k"] == null`, true},
+		{`def m = {"k": 1}; m["k"] == null`, false},
+		{"null is null", true},
+		{"0 is null", false},
+		{"0 is not null", true},
+		{`{}["k"] is null`, true},
+		{"def x = null; x is not null && x[0]", false},
+		{"null is not 0", true},
+		{"1 is 1", "`is` only checks for null, use == to compare INTEGER and INTEGER"},
+		{`"a" is not "a"`, "`is not` only checks for null, use == to compare STRING and STRING"},
+		{"!null", true},
+		{"null ?? 1", 1},
+		{`type(null)`, "NULL"},
+		{"if (true) {}", nil},
+		{"if (false) { 1 }", nil},
+		{"func() {}()", nil},
+		{"def f = func() { def x = 1 }; f()", nil},
+		{"def x = if (true) {}; x is null", true},
+		{"null + 1", "type mismatch: NULL + INTEGER"},
+		{"null < null", "unknown operator: NULL < NULL"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("%q: expected %q, got %q", tt.input, expected, obj.Value)
+				}
+			case *object.Error:
+				if obj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q",
+						expected, obj.Message)
+				}
+			default:
+				t.Errorf("%q: unexpected result %T(%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestMapBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 2, "a": 1, "c": 3}`, "{b: 2, a: 1, c: 3}"},
+		{`def m = {"b": 2}; m["a"] = 1; m["b"] = 3; m`, "{b: 3, a: 1}"},
+		{`def keys = []; for (k in {"b": 2, "a": 1}) { keys = push(keys, k) }; keys`, "[b, a]"},
+		{`keys({"b": 2, "a": 1})`, "[b, a]"},
+		{`values({"b": 2, "a": 1})`, "[2, 1]"},
+		{`items({"b": 2, "a": 1})`, "[[b, 2], [a, 1]]"},
+		{`keys({})`, "[]"},
+		{`has({"a": 1}, "a")`, "true"},
+		{`has({"a": 1}, "b")`, "false"},
+		{`has({1: null}, 1)`, "true"},
+		{`def m = {"a": 1, "b": 2, "c": 3}; delete(m, "b")`, "2"},
+		{`def m = {"a": 1, "b": 2, "c": 3}; delete(m, "b"); m`, "{a: 1, c: 3}"},
+		{`def m = {"a": 1}; delete(m, "x")`, "null"},
+		{`def m = {"a": 1, "b": 2}; delete(m, "a"); m["a"] = 3; m`, "{b: 2, a: 3}"},
+		{`merge({"a": 1, "b": 2}, {"b": 3, "c": 4})`, "{a: 1, b: 3, c: 4}"},
+		{`def m = {"a": 1}; merge(m, {"a": 2}); m`, "{a: 1}"},
+		{`merge({}, {1: 1}, {2: 2})`, "{1: 1, 2: 2}"},
+		{`len({"a": 1, "b": 2})`, "2"},
+		{`keys([1])`, "ERROR: argument to `keys` must be MAP, got ARRAY"},
+		{`has({}, [1])`, "ERROR: unusable as hash key: ARRAY"},
+		{`merge({}, 1)`, "ERROR: argument to `merge` must be MAP, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{3, 1, 2, 1}`, "{3, 1, 2}"},
+		{`{1, 1.0, 1n}`, "{1}"},
+		{`type({1})`, "SET"},
+		{`set()`, "set()"},
+		{`set([2, 1, 2])`, "{2, 1}"},
+		{`set("abca")`, "{a, b, c}"},
+		{`set({"a": 1, "b": 2})`, "{a, b}"},
+		{`len({1, 2})`, "2"},
+		{`{1, 2, 3} | {3, 4}`, "{1, 2, 3, 4}"},
+		{`{1, 2, 3} & {3, 2, 5}`, "{2, 3}"},
+		{`{1, 2, 3} - {2}`, "{1, 3}"},
+		{`{1, 2, 3} ^ {3, 4}`, "{1, 2, 4}"},
+		{`{1, 2} == {2, 1}`, "true"},
+		{`{1, 2} != {1}`, "true"},
+		{`{1} < {1, 2}`, "true"},
+		{`{1, 2} < {1, 2}`, "false"},
+		{`{1, 2} <= {1, 2}`, "true"},
+		{`{1, 2, 3} > {3}`, "true"},
+		{`2 in {1, 2}`, "true"},
+		{`3 not in {1, 2}`, "true"},
+		{`[1] in {1}`, "false"},
+		{`"a" in {"a": 1}`, "true"},
+		{`1 in {"a": 1}`, "false"},
+		{`2 in [1, 2]`, "true"},
+		{`"ell" in "hello"`, "true"},
+		{`"x" not in "hello"`, "true"},
+		{`def s = {1}; add(s, 2, 1); s`, "{1, 2}"},
+		{`def s = {1, 2, 3}; delete(s, 2); s`, "{1, 3}"},
+		{`delete({1}, 5)`, "null"},
+		{`has({1, 2}, 2)`, "true"},
+		{`def out = []; for (x in {"b", "a"}) { out = push(out, x) }; out`, "[b, a]"},
+		{`{[1]}`, "ERROR: unusable as set element: ARRAY"},
+		{`set(1)`, "ERROR: cannot iterate over INTEGER"},
+		{`add([], 1)`, "ERROR: argument to `add` must be SET, got ARRAY"},
+		{`1 in "a"`, "ERROR: substring must be STRING, got INTEGER"},
+		{`1 in 2`, "ERROR: `in` not supported: INTEGER"},
+		{`{1} + {2}`, "ERROR: unknown operator: SET + SET"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestTuplesAndFreeze(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`(1, "a",)`, "(1, a)"},
+		{`(1,)`, "(1,)"},
+		{`()`, "()"},
+		{`type((1, 2))`, "TUPLE"},
+		{`len((1, 2, 3))`, "3"},
+		{`(1, 2)[1]`, "2"},
+		{`(1, 2)[2]`, "null"},
+		{`tuple([1, 2])`, "(1, 2)"},
+		{`tuple({"a": 1})`, "(a,)"},
+		{`tuple()`, "()"},
+		{`(1, 2) == (1, 2)`, "true"},
+		{`(1, 2) == [1, 2]`, "false"},
+		{`(1, 2) < (1, 3)`, "true"},
+		{`2 in (1, 2)`, "true"},
+		{`def out = []; for (x in (1, 2)) { out = push(out, x) }; out`, "[1, 2]"},
+		{`{(1, 2): "x"}[(1, 2)]`, "x"},
+		{`{(1, 2): "x"}[(1.0, 2)]`, "x"},
+		{`{(1, 2): "x"}[(2, 1)]`, "null"},
+		{`{freeze([1, 2]): "x"}[freeze([1, 2])]`, "x"},
+		{`{(1, 2), (1, 2)}`, "{(1, 2)}"},
+		{`def a = [1, 2]; freeze(a); a`, "[1, 2]"},
+		{`{(1, [2]): 1}`, "ERROR: unusable as hash key: TUPLE"},
+		{`{[1]: 1}`, "ERROR: unusable as hash key: ARRAY"},
+		{`def t = (1, 2); t[0] = 3`, "ERROR: index assignment not supported: TUPLE"},
+		{`def a = freeze([1, 2]); a[0] = 3`, "ERROR: cannot modify frozen ARRAY"},
+		{`def a = freeze([[1]]); def b = a[0]; b[0] = 2`, "ERROR: cannot modify frozen ARRAY"},
+		{`def m = freeze({"a": [1]}); m["b"] = 2`, "ERROR: cannot modify frozen MAP"},
+		{`def m = freeze({"a": [1]}); m["a"][0] += 1`, "ERROR: cannot modify frozen ARRAY"},
+		{`def m = freeze({"a": 1}); delete(m, "a")`, "ERROR: cannot modify frozen MAP"},
+		{`def s = freeze({1}); add(s, 2)`, "ERROR: cannot modify frozen SET"},
+		{`def a = freeze([1]); push(a, 2)`, "[1, 2]"},
+		{`def a = [1]; a[0] = a; freeze(a); len(a)`, "1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCyclicValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`def a = [1, 2]; a[0] = a; a`, "[[...], 2]"},
+		{`def a = [1, 2]; a[0] = a; a == a`, "true"},
+		{`def a = [1]; a[0] = a; def b = [1]; b[0] = b; a == b`, "true"},
+		{`def a = [1]; a[0] = a; a < a`, "false"},
+		{`def a = [1]; a[0] = a; a in [a]`, "true"},
+		{`def m = {}; m["m"] = m; m`, "{m: {...}}"},
+		{`def m = {}; m.m = m; m == m`, "true"},
+		{`struct P { x }; def p = P(1); p.x = p; p`, "P{x: P{...}}"},
+		{`def t = ([0],); t[0][0] = t; t`, "([(...)],)"},
+		{`def a = [1]; a[0] = a; freeze(a); {a: 1}`, "ERROR: unusable as hash key: ARRAY"},
+		{`def a = [1]; a[0] = a; freeze(a); set([a])`, "ERROR: unusable as set element: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil {
+			t.Errorf("%q: expected %s, got nil", tt.input, tt.expected)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`struct Point { x, y }; Point(1, 2)`, "Point{x: 1, y: 2}"},
+		{`struct Point { x, y }; Point`, "struct Point { x, y }"},
+		{`struct Point { x, y }; type(Point(1, 2))`, "Point"},
+		{`struct Point { x, y }; type(Point)`, "STRUCT"},
+		{`struct Point { x, y }; Point(1, 2).y`, "2"},
+		{`struct Point { x, y }; def p = Point(1, 2); p.x = 5; p`, "Point{x: 5, y: 2}"},
+		{`struct Point { x, y }; def p = Point(1, 2); p.y += 3; p.y`, "5"},
+		{`struct Point { x, y }; Point(1, 2) == Point(1, 2)`, "true"},
+		{`struct Point { x, y }; Point(1, 2) == Point(1, 3)`, "false"},
+		{`struct A { x }; struct B { x }; A(1) == B(1)`, "false"},
+		{`struct Point { x, y }; Point(1, [2]) == Point(1.0, [2])`, "true"},
+		{`struct Empty {}; Empty()`, "Empty{}"},
+		{`struct Line { from, to }; struct P { x }; def l = Line(P(0), P(1)); l.to.x = 9; l`,
+			"Line{from: P{x: 0}, to: P{x: 9}}"},
+		{`struct P { x }; def p = null; p?.x`, "null"},
+		{`def m = {"a": {"b": 1}}; m.a.b = 2; m`, "{a: {b: 2}}"},
+		{`def m = {"a": 1}; m.a += 1; m.a`, "2"},
+		{`struct Point { x, y }; Point(1, 2).z`, "ERROR: Point has no field z"},
+		{`struct Point { x, y }; def p = Point(1, 2); p.z = 1`, "ERROR: Point has no field z"},
+		{`struct Point { x, y }; Point(1)`, "ERROR: wrong number of arguments to `Point`. got=1, want=2"},
+		{`struct P { x }; def p = freeze(P([1])); p.x = 2`, "ERROR: cannot modify frozen P"},
+		{`struct P { x }; def p = freeze(P([1])); p.x[0] = 2`, "ERROR: cannot modify frozen ARRAY"},
+		{`def a = [1]; a.x = 1`, "ERROR: cannot assign to field x of ARRAY"},
+		{`def n = 5; n.x`, "ERROR: cannot access field x of INTEGER"},
+		{`struct STRING { x }; STRING(1) + "a"`, "ERROR: cannot name a struct STRING, it is a builtin type"},
+		{`struct MAP { x }`, "ERROR: cannot name a struct MAP, it is a builtin type"},
+		{`struct ERROR { x }`, "ERROR: cannot name a struct ERROR, it is a builtin type"},
+		{`struct Integer { x }; Integer(1)`, "Integer{x: 1}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	rect := `struct Rect { w, h }
+impl Rect {
+	def area = func(self) { self.w * self.h };
+	def scale = func(self, k) { Rect(self.w * k, self.h * k) };
+	def grow = func(self) { self.w += 1; self };
+};
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{rect + `Rect(2, 3).area()`, "6"},
+		{rect + `Rect(2, 3).scale(2)`, "Rect{w: 4, h: 6}"},
+		{rect + `Rect(2, 3).scale(2).area()`, "24"},
+		{rect + `def r = Rect(2, 3); r.grow(); r`, "Rect{w: 3, h: 3}"},
+		{rect + `def area = Rect(2, 3).area; area()`, "6"},
+		{rect + `Rect(2, 3).area`, "method Rect.area"},
+		{rect + `type(Rect(2, 3).area)`, "METHOD"},
+		{rect + `impl Rect { def area = func(self) { 0 }; }; Rect(2, 3).area()`, "0"},
+		{`struct P { f }; impl P { def f = func(self) { 1 }; }; P(2).f`, "2"},
+		{`"abc".upper()`, "ABC"},
+		{`"ABC".lower()`, "abc"},
+		{`"  x ".trim()`, "x"},
+		{`"a,b,c".split(",")`, "[a, b, c]"},
+		{`" a  b ".split()`, "[a, b]"},
+		{`"héllo".len()`, "6"},
+		{`["a", "b"].join("-")`, "a-b"},
+		{`[3, 1, 2].sort().push(4)`, "[1, 2, 3, 4]"},
+		{`def a = [1]; a.push(2); a`, "[1, 2]"},
+		{`def a = [1]; push(a, 2); a`, "[1]"},
+		{`def a = [1]; a.push(2).push(3)`, "[1, 2, 3]"},
+		{`def a = [1, 2, 3]; a.pop()`, "3"},
+		{`def a = [1, 2, 3]; a.pop(0); a`, "[2, 3]"},
+		{`def a = [1]; a.pop(5)`, "null"},
+		{`[].pop()`, "null"},
+		{`def a = [3, 1, 2]; a.sort(); a`, "[1, 2, 3]"},
+		{`def m = {"a": 1}; m.merge({"b": 2}, {"a": 3}); m`, "{a: 3, b: 2}"},
+		{`def a = freeze([1]); a.push(2)`, "ERROR: cannot modify frozen ARRAY"},
+		{`def a = freeze([1]); a.pop()`, "ERROR: cannot modify frozen ARRAY"},
+		{`freeze([2, 1]).sort()`, "ERROR: cannot modify frozen ARRAY"},
+		{`freeze({}).merge({"a": 1})`, "ERROR: cannot modify frozen MAP"},
+		{`[1].push()`, "ERROR: wrong number of arguments to `push`. got=0, want=1"},
+		{`[1].pop("a")`, "ERROR: argument to `pop` must be INTEGER, got STRING"},
+		{`[1, "a"].sort()`, "ERROR: cannot compare a and 1 in `sort`"},
+		{`[1, 2, 3].first()`, "1"},
+		{`(1, 2).len()`, "2"},
+		{`{"a": 1, "b": 2}.keys()`, "[a, b]"},
+		{`{"a": 1}.has("a")`, "true"},
+		{`def m = {"f": func(x) { x * 2 }}; m.f(4)`, "8"},
+		{`{"keys": 1}.keys`, "1"},
+		{`def s = {1}; s.add(2); s`, "{1, 2}"},
+		{`upper("abc")`, "ABC"},
+		{rect + `Rect(2, 3).area(1)`, "ERROR: wrong number of arguments to `Rect.area`. got=1, want=0"},
+		{rect + `Rect(2, 3).volume()`, "ERROR: Rect has no field volume"},
+		{`"abc".reverse()`, "ERROR: cannot access field reverse of STRING"},
+		{`struct P {}; impl P { def x = 1; }`, "ERROR: method x of P must be a function, got INTEGER"},
+		{`struct P {}; impl P { def x = func() { 1 }; }`, "ERROR: method x of P needs a self parameter"},
+		{`def Q = 1; impl Q { }`, "ERROR: impl needs a struct, got INTEGER"},
+		{`upper(1)`, "ERROR: argument to `upper` must be STRING, got INTEGER"},
+		{`["a", 1].join("")`, "ERROR: cannot join INTEGER, only STRINGs"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil {
+			t.Errorf("%q: expected %s, got nil", tt.input, tt.expected)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEnumsAndMatch(t *testing.T) {
+	shape := `enum Shape { Circle(r), Rect(w, h), Empty }
+def area = func(s) {
+	match (s) {
+		Shape.Circle(r) => 3 * r * r,
+		Shape.Rect(w, h) if w == h => "square",
+		Shape.Rect(w, h) => w * h,
+		Shape.Empty => 0,
+	}
+};
+`
+	kind := `def kind = func(x) {
+	match (x) {
+		0 => "zero",
+		-9..-1 => "negative",
+		1..9 => "digit",
+		"a".."z" => "letter",
+		null => "null",
+		[] => "empty",
+		[x] => x * 100,
+		[a, b] => a + b,
+		[h, ..t] => t,
+		(a, b) => a * b,
+		{"name": n, "age": a} if a >= 18 => n + " adult",
+		{"name": n} => n,
+		_ => "other",
+	}
+};
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{shape + `area(Shape.Circle(2))`, "12"},
+		{shape + `area(Shape.Rect(2, 3))`, "6"},
+		{shape + `area(Shape.Rect(2, 2))`, "square"},
+		{shape + `area(Shape.Empty)`, "0"},
+		{shape + `Shape.Circle(2)`, "Shape.Circle(2)"},
+		{shape + `Shape.Empty`, "Shape.Empty"},
+		{shape + `Shape`, "enum Shape { Circle(r), Rect(w, h), Empty }"},
+		{shape + `Shape.Circle`, "variant Shape.Circle(r)"},
+		{shape + `Shape.Rect(2, 3).h`, "3"},
+		{shape + `type(Shape.Empty)`, "Shape"},
+		{shape + `Shape.Circle(1) == Shape.Circle(1)`, "true"},
+		{shape + `Shape.Circle(1) == Shape.Circle(2)`, "false"},
+		{shape + `Shape.Empty == Shape.Empty`, "true"},
+		{kind + `kind(0)`, "zero"},
+		{kind + `kind(-3)`, "negative"},
+		{kind + `kind(9)`, "digit"},
+		{kind + `kind(9.5)`, "other"},
+		{kind + `kind("q")`, "letter"},
+		{kind + `kind(null)`, "null"},
+		{kind + `kind([])`, "empty"},
+		{kind + `kind([7])`, "700"},
+		{kind + `kind([1, 2])`, "3"},
+		{kind + `kind([1, 2, 3])`, "[2, 3]"},
+		{kind + `kind((3, 4))`, "12"},
+		{kind + `kind((3, 4, 5))`, "other"},
+		{kind + `kind({"name": "Ann", "age": 30})`, "Ann adult"},
+		{kind + `kind({"name": "Bo", "age": 3})`, "Bo"},
+		{kind + `kind({"age": 3})`, "other"},
+		{`struct P { x, y }; match (P(1, 2)) { P(1, y) => y, _ => 0 }`, "2"},
+		{`struct P { x, y }; match (P(3, 2)) { P(1, y) => y, P(_, _) => "P" }`, "P"},
+		{`struct P { x, y }; match (5) { P(_, _) => "P", P => P }`, "5"},
+		{`enum STRING { A(x) }; STRING.A(1) + "a"`, "ERROR: cannot name an enum STRING, it is a builtin type"},
+		{`def x = 1; match (5) { x => x }; x`, "1"},
+		{`match (2) { n if n > 5 => "big", n => { def m = n * 10; m } }`, "20"},
+		{`match ([1, [2, 3]]) { [a, [b, c]] => a + b + c }`, "6"},
+		{`match (100) { 1..9 => 1 }`, "ERROR: non-exhaustive match: no arm matches 100"},
+		{`match (100000.05) { 0..100000 => "in", _ => "out" }`, "out"},
+		{shape + `match (Shape.Empty) { Shape.Circle(r) => r }`, "ERROR: non-exhaustive match: no arm matches Shape.Empty"},
+		{shape + `match (Shape.Empty) { Shape.Empty(x) => x }`, "ERROR: Shape.Empty has no fields"},
+		{shape + `match (Shape.Circle(1)) { Shape.Circle(a, b) => a }`,
+			"ERROR: wrong number of fields in pattern (Shape.Circle)(a, b). got=2, want=1"},
+		{shape + `Shape.Square`, "ERROR: Shape has no variant Square"},
+		{shape + `Shape.Circle(1, 2)`, "ERROR: wrong number of arguments to `Shape.Circle`. got=2, want=1"},
+		{shape + `Shape.Empty.r`, "ERROR: Shape.Empty has no field r"},
+		{`def f = 1; match (1) { f(x) => x }`, "ERROR: cannot match against INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil {
+			t.Errorf("%q: expected %s, got nil", tt.input, tt.expected)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package evaluator
+
+import (
+	"github.com/batt0s/rizzy/ast"
+	"github.com/batt0s/rizzy/object"
+)
+
+// newEnumType makes the type of an enum statement. Each variant without
+// fields gets its single value up front, so Shape.Empty is always the
+// same object.
+func newEnumType(node *ast.EnumStatement) *object.EnumType {
+	enum := &object.EnumType{Name: node.Name.Value}
+	for _, v := range node.Variants {
+		variant := &object.EnumVariant{Enum: enum, Name: v.Name.Value}
+		for _, field := range v.Fields {
+			variant.Fields = append(variant.Fields, field.Value)
+		}
+		if v.Fields == nil {
+			variant.Unit = &object.EnumValue{Variant: variant}
+		}
+		enum.Variants = append(enum.Variants, variant)
+	}
+	return enum
+}
+
+// evalMatchExpression evaluates the body of the first arm whose pattern
+// matches the subject and whose guard, if any, is truthy. The names a
+// pattern binds are only visible in its guard and body. It is an error
+// when no arm matches.
+func evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(node.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+
+	for _, arm := range node.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+
+		ok, err := matchPattern(arm.Pattern, subject, armEnv)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isThruty(guard) {
+				continue
+			}
+		}
+
+		return Eval(arm.Body, armEnv)
+	}
+
+	return newError("non-exhaustive match: no arm matches %s", subject.Inspect())
+}
+
+// matchPattern tells whether value matches pattern, and binds the names
+// in the pattern in env. err is set if the pattern can't be evaluated.
+func matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment) (ok bool, err object.Object) {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true, nil
+	case *ast.BindingPattern:
+		env.Set(pattern.Name.Value, value)
+		return true, nil
+	case *ast.LiteralPattern:
+		literal := Eval(pattern.Value, env)
+		if isError(literal) {
+			return false, literal
+		}
+		return object.Equal(literal, value), nil
+	case *ast.RangePattern:
+		return matchRange(pattern, value, env)
+	case *ast.ArrayPattern:
+		array, ok := value.(*object.Array)
+		if !ok {
+			return false, nil
+		}
+		return matchArray(pattern, array.Elements, env)
+	case *ast.TuplePattern:
+		tuple, ok := value.(*object.Tuple)
+		if !ok || len(tuple.Elements) != len(pattern.Elements) {
+			return false, nil
+		}
+		return matchElements(pattern.Elements, tuple.Elements, env)
+	case *ast.MapPattern:
+		m, ok := value.(*object.Map)
+		if !ok {
+			return false, nil
+		}
+		return matchMap(pattern, m, env)
+	case *ast.ConstructorPattern:
+		return matchConstructor(pattern, value, env)
+	}
+
+	return false, newError("unknown pattern: %s", pattern.String())
+}
+
+// matchRange tells whether value lies between the bounds of the pattern,
+// both included. Values that can't be ordered with the bounds don't match.
+func matchRange(pattern *ast.RangePattern, value object.Object, env *object.Environment) (bool, object.Object) {
+	low := Eval(pattern.Low, env)
+	if isError(low) {
+		return false, low
+	}
+	high := Eval(pattern.High, env)
+	if isError(high) {
+		return false, high
+	}
+
+	c, ok := object.Compare(low, value)
+	if !ok || c > 0 {
+		return false, nil
+	}
+	c, ok = object.Compare(value, high)
+	return ok && c <= 0, nil
+}
+
+// matchArray matches the elements of an array. Without a rest the array
+// has to have as many elements as the pattern, with one it needs at least
+// as many, and the rest is bound to a new array of the others.
+func matchArray(pattern *ast.ArrayPattern, elements []object.Object, env *object.Environment) (bool, object.Object) {
+	n := len(pattern.Elements)
+	if len(elements) < n || (!pattern.HasRest && len(elements) != n) {
+		return false, nil
+	}
+
+	ok, err := matchElements(pattern.Elements, elements[:n], env)
+	if !ok || err != nil {
+		return ok, err
+	}
+
+	if pattern.Rest != nil {
+		rest := make([]object.Object, len(elements)-n)
+		copy(rest, elements[n:])
+		env.Set(pattern.Rest.Value, &object.Array{Elements: rest})
+	}
+	return true, nil
+}
+
+func matchElements(patterns []ast.Pattern, elements []object.Object, env *object.Environment) (bool, object.Object) {
+	for i, p := range patterns {
+		ok, err := matchPattern(p, elements[i], env)
+		if !ok || err != nil {
+			return ok, err
+		}
+	}
+	return true, nil
+}
+
+// matchMap matches a map that has all the keys of the pattern. Other keys
+// of the map are ignored.
+func matchMap(pattern *ast.MapPattern, m *object.Map, env *object.Environment) (bool, object.Object) {
+	for i, k := range pattern.Keys {
+		key := Eval(k, env)
+		if isError(key) {
+			return false, key
+		}
+		hashKey, ok := object.AsHashable(key)
+		if !ok {
+			return false, newError("unusable as hash key: %s", key.Type())
+		}
+
+		v, ok := m.Get(hashKey)
+		if !ok {
+			return false, nil
+		}
+		ok, err := matchPattern(pattern.Values[i], v, env)
+		if !ok || err != nil {
+			return ok, err
+		}
+	}
+	return true, nil
+}
+
+// matchConstructor matches an enum variant, Shape.Circle(r) or
+// Shape.Empty, or a struct, Point(x, y), whose fields are matched in
+// order.
+func matchConstructor(pattern *ast.ConstructorPattern, value object.Object, env *object.Environment) (bool, object.Object) {
+	typ := Eval(pattern.Type, env)
+	if isError(typ) {
+		return false, typ
+	}
+
+	var values []object.Object
+	var fields int
+	switch typ := typ.(type) {
+	case *object.EnumVariant:
+		v, ok := value.(*object.EnumValue)
+		if !ok || v.Variant != typ {
+			return false, nil
+		}
+		values, fields = v.Values, len(typ.Fields)
+	case *object.EnumValue:
+		if pattern.HasArgs {
+			return false, newError("%s has no fields", typ.Inspect())
+		}
+		return value == typ, nil
+	case *object.StructType:
+		s, ok := value.(*object.Struct)
+		if !ok || s.Def != typ {
+			return false, nil
+		}
+		values, fields = s.Values, len(typ.Fields)
+	default:
+		return false, newError("cannot match against %s", typ.Type())
+	}
+
+	if !pattern.HasArgs {
+		return true, nil
+	}
+	if len(pattern.Args) != fields {
+		return false, newError("wrong number of fields in pattern %s. got=%d, want=%d",
+			pattern.String(), len(pattern.Args), fields)
+	}
+	return matchElements(pattern.Args, values, env)
+}
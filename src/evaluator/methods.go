@@ -0,0 +1,135 @@
+package evaluator
+
+import "github.com/batt0s/rizzy/object"
+
+// builtinMethods are the methods of the builtin types, by type and name.
+// Most are the builtin functions of the same name, which get the value
+// the method is called on as their first argument: "abc".upper() is
+// upper("abc"). The ones in inPlaceMethods change that value instead.
+var builtinMethods = map[object.ObjectType]map[string]object.Object{
+	object.STRING_OBJ: methods("len", "upper", "lower", "trim", "split"),
+	object.ARRAY_OBJ: methods("len", "first", "last", "head", "tail", "push", "pop",
+		"sort", "join"),
+	object.TUPLE_OBJ: methods("len"),
+	object.MAP_OBJ:   methods("len", "keys", "values", "items", "has", "delete", "merge"),
+	object.SET_OBJ:   methods("len", "has", "add", "delete"),
+}
+
+// inPlaceMethods change the value they are called on, where the builtin
+// function of the same name returns a changed copy: arr.push(4) adds 4
+// to arr, push(arr, 4) returns a new array.
+var inPlaceMethods = map[string]object.Object{
+	"push":  &object.Builtin{Fn: method_push},
+	"pop":   &object.Builtin{Fn: method_pop},
+	"sort":  &object.Builtin{Fn: method_sort},
+	"merge": &object.Builtin{Fn: method_merge},
+}
+
+func methods(names ...string) map[string]object.Object {
+	m := make(map[string]object.Object, len(names))
+	for _, name := range names {
+		if method, ok := inPlaceMethods[name]; ok {
+			m[name] = method
+		} else {
+			m[name] = builtins[name]
+		}
+	}
+	return m
+}
+
+// lookupMethod returns the method of obj with the given name: one added
+// with an impl block for a struct, a builtin method for the other types.
+func lookupMethod(obj object.Object, name string) (object.Object, bool) {
+	if s, ok := obj.(*object.Struct); ok {
+		method, ok := s.Def.Methods[name]
+		return method, ok
+	}
+	method, ok := builtinMethods[obj.Type()][name]
+	return method, ok
+}
+
+// method_push adds an element to the end of the array and returns the
+// array, so calls can be chained: arr.push(1).push(2).
+func method_push(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments to `push`. got=%d, want=1",
+			len(args)-1)
+	}
+	arr := args[0].(*object.Array)
+	if arr.Frozen {
+		return newError("cannot modify frozen ARRAY")
+	}
+
+	arr.Elements = append(arr.Elements, args[1])
+	return arr
+}
+
+// method_pop removes the last element of the array, or the one at the
+// given index, and returns it. It returns null if there is no such
+// element.
+func method_pop(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return newError("wrong number of arguments to `pop`. got=%d, want=0 or 1",
+			len(args)-1)
+	}
+	arr := args[0].(*object.Array)
+	if arr.Frozen {
+		return newError("cannot modify frozen ARRAY")
+	}
+
+	idx := int64(len(arr.Elements) - 1)
+	if len(args) == 2 {
+		index, ok := args[1].(*object.Integer)
+		if !ok {
+			return newError("argument to `pop` must be INTEGER, got %s", args[1].Type())
+		}
+		idx = index.Value
+	}
+	if idx < 0 || idx >= int64(len(arr.Elements)) {
+		return NULL
+	}
+
+	removed := arr.Elements[idx]
+	arr.Elements = append(arr.Elements[:idx:idx], arr.Elements[idx+1:]...)
+	return removed
+}
+
+// method_sort sorts the array in place and returns it.
+func method_sort(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments to `sort`. got=%d, want=0",
+			len(args)-1)
+	}
+	arr := args[0].(*object.Array)
+	if arr.Frozen {
+		return newError("cannot modify frozen ARRAY")
+	}
+
+	sorted := builtin_sort(arr)
+	if isError(sorted) {
+		return sorted
+	}
+	arr.Elements = sorted.(*object.Array).Elements
+	return arr
+}
+
+// method_merge adds the pairs of the given maps to the map and returns
+// it. When several maps have the same key, the value of the last one
+// wins.
+func method_merge(args ...object.Object) object.Object {
+	m := args[0].(*object.Map)
+	if m.Frozen {
+		return newError("cannot modify frozen MAP")
+	}
+
+	for _, arg := range args[1:] {
+		other, ok := arg.(*object.Map)
+		if !ok {
+			return newError("argument to `merge` must be MAP, got %s", arg.Type())
+		}
+		for _, pair := range other.Pairs() {
+			m.Set(pair.Key.(object.Hashable), pair.Value)
+		}
+	}
+	return m
+}
@@ -1,15 +1,29 @@
 package lexer
 
-import "github.com/batt0s/rizzy/token"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/batt0s/rizzy/token"
+)
 
 type Lexer struct {
 	input        string
 	position     int
 	readPosition int
-	ch           byte
+	ch           rune // current character, 0 at the end of input
 
 	line int
 	col  int
+
+	keepComments bool
+
+	// interpolations holds, for each "${" the lexer is inside of, how
+	// many braces were opened since, so it knows which "}" closes it.
+	interpolations []int
 }
 
 func New(input string) *Lexer {
@@ -17,39 +31,81 @@ func New(input string) *Lexer {
 	l.position = 0
 	l.readPosition = 0
 	l.line = 1
-	l.col = 1
+	l.col = 0
 	l.readChar()
+	if l.ch == '\uFEFF' {
+		// Skip the byte order mark some editors put at the start of files.
+		l.readChar()
+		l.col = 1
+	}
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.col = 0
+	}
+	width := 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPosition]
-		if l.ch == '\n' {
-			l.line += 1
-			l.col = 1
-		} else {
-			l.col += 1
-		}
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 	}
+	l.col += 1
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += width
 }
 
+// KeepComments makes the lexer return comments as COMMENT tokens instead
+// of skipping them.
+func (l *Lexer) KeepComments(keep bool) {
+	l.keepComments = keep
+}
+
+// Trace returns the line and column of the character the lexer is
+// currently looking at. Columns are counted in characters, not bytes.
 func (l *Lexer) Trace() (int, int) {
 	return l.line, l.col
 }
 
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func (l *Lexer) currentPos() token.Position {
+	offset := l.position
+	if offset > len(l.input) {
+		offset = len(l.input)
+	}
+	return token.Position{Line: l.line, Column: l.col, Offset: offset}
+}
+
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
+// readTwoCharToken reads the current and the next character as one token.
+func (l *Lexer) readTwoCharToken(tokenType token.TokenType) token.Token {
+	ch := l.ch
+	l.readChar()
+	return token.Token{Type: tokenType, Literal: string(ch) + string(l.ch)}
+}
+
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
+	for {
+		l.skipWhitespace()
+
+		start := l.currentPos()
+		tok := l.readToken()
+		tok.Pos = start
+		tok.End = l.currentPos()
+
+		if tok.Type == token.COMMENT && !l.keepComments {
+			continue
+		}
+		return tok
+	}
+}
 
-	l.skipWhitespace()
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
 
 	switch l.ch {
 	case '=':
@@ -60,6 +116,8 @@ func (l *Lexer) NextToken() token.Token {
 				Type:    token.EQ,
 				Literal: string(ch) + string(l.ch),
 			}
+		} else if l.peekChar() == '>' {
+			tok = l.readTwoCharToken(token.ARROW)
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
@@ -72,9 +130,17 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.readTwoCharToken(token.PLUS_ASSIGN)
+		} else {
+			tok = newToken(token.PLUS, l.ch)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '=' {
+			tok = l.readTwoCharToken(token.MINUS_ASSIGN)
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -87,11 +153,35 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		switch l.peekChar() {
+		case '/':
+			return l.readLineComment()
+		case '*':
+			return l.readBlockComment()
+		case '=':
+			tok = l.readTwoCharToken(token.SLASH_ASSIGN)
+		default:
+			tok = newToken(token.SLASH, l.ch)
+		}
+	case '#':
+		return l.readLineComment()
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		switch l.peekChar() {
+		case '=':
+			tok = l.readTwoCharToken(token.ASTERISK_ASSIGN)
+		case '*':
+			tok = l.readTwoCharToken(token.POWER)
+		default:
+			tok = newToken(token.ASTERISK, l.ch)
+		}
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
+	case '^':
+		tok = newToken(token.BIT_XOR, l.ch)
 	case '<':
-		if l.peekChar() == '=' {
+		if l.peekChar() == '<' {
+			tok = l.readTwoCharToken(token.SHL)
+		} else if l.peekChar() == '=' {
 			ch := l.ch
 			l.readChar()
 			tok = token.Token{
@@ -102,7 +192,9 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.LT, l.ch)
 		}
 	case '>':
-		if l.peekChar() == '=' {
+		if l.peekChar() == '>' {
+			tok = l.readTwoCharToken(token.SHR)
+		} else if l.peekChar() == '=' {
 			ch := l.ch
 			l.readChar()
 			tok = token.Token{
@@ -134,19 +226,55 @@ func (l *Lexer) NextToken() token.Token {
 		} else {
 			tok = newToken(token.BIT_OR, l.ch)
 		}
+	case '?':
+		switch l.peekChar() {
+		case '?':
+			tok = l.readTwoCharToken(token.NULLISH)
+		case '.':
+			// c ?.5 : 1 is a conditional, like in JavaScript.
+			if isDigit(l.peekSecondChar()) {
+				tok = newToken(token.QUESTION, l.ch)
+				break
+			}
+			tok = l.readTwoCharToken(token.OPT_DOT)
+		case '[':
+			tok = l.readTwoCharToken(token.OPT_LBRACKET)
+		default:
+			tok = newToken(token.QUESTION, l.ch)
+		}
 	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1]++
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
+		if n := len(l.interpolations); n > 0 {
+			if l.interpolations[n-1] == 0 {
+				l.interpolations = l.interpolations[:n-1]
+				tok = l.readString(false)
+				break
+			}
+			l.interpolations[n-1]--
+		}
 		tok = newToken(token.RBRACE, l.ch)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok = l.readString(true)
+	case '`':
+		tok = l.readRawString()
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		if l.peekChar() == '.' {
+			tok = l.readTwoCharToken(token.DOTDOT)
+		} else if isDigit(l.peekChar()) {
+			return l.readNumber() // .5
+		} else {
+			tok = newToken(token.DOT, l.ch)
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -156,18 +284,11 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
-			if l.ch == '.' {
-				tok.Type = token.FLOAT
-				l.readChar()
-				if isDigit(l.ch) {
-					tok.Literal += "." + l.readNumber()
-				}
-			}
-			return tok
+			return l.readNumber()
+		} else if l.ch == utf8.RuneError {
+			tok = l.illegal("invalid UTF-8 encoding")
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			tok = l.illegal("unexpected character %q", l.ch)
 		}
 	}
 
@@ -181,45 +302,288 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+// readIdentifier reads a letter followed by any letters and digits,
+// Unicode ones included, like "größe", "名前" or "var1".
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || isDigit(l.ch) || l.ch >= utf8.RuneSelf && unicode.IsDigit(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer or float literal: decimal, hex (0xFF),
+// octal (0o755) or binary (0b1010) integers and decimal floats with an
+// optional exponent (1.5e-3). Digits may be separated by underscores.
+// An "n" suffix makes an integer a BIGINT (123n) and a "d" suffix makes
+// a decimal number a DECIMAL (1.50d). The literal is validated by the
+// parser.
+func (l *Lexer) readNumber() token.Token {
 	position := l.position
-	for isDigit(l.ch) {
+	var tokenType token.TokenType = token.INT
+
+	prefixed := l.ch == '0' && isBasePrefix(l.peekChar())
+	if prefixed {
+		l.readChar()
+		l.readChar()
+	} else {
+		l.readDigits()
+		// 1..5 is a range, not the float 1. followed by .5
+		if l.ch == '.' && l.peekChar() != '.' {
+			tokenType = token.FLOAT
+			l.readChar()
+			l.readDigits()
+		}
+		if l.ch == 'e' || l.ch == 'E' {
+			tokenType = token.FLOAT
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+		}
+	}
+
+	// Read whatever letters and digits follow as part of the literal,
+	// so "0b102" or "12abc" are reported as one malformed number.
+	for isLetter(l.ch) || isDigit(l.ch) {
+		l.readChar()
+	}
+
+	literal := l.input[position:l.position]
+	switch {
+	case strings.HasSuffix(literal, "n"):
+		tokenType = token.BIGINT
+	case strings.HasSuffix(literal, "d") && !prefixed:
+		tokenType = token.DECIMAL
+	}
+
+	return token.Token{Type: tokenType, Literal: literal}
+}
+
+func (l *Lexer) readDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
 		l.readChar()
 	}
-	return l.input[position:l.position]
 }
 
-func (l *Lexer) peekChar() byte {
+func isBasePrefix(ch rune) bool {
+	switch ch {
+	case 'x', 'X', 'o', 'O', 'b', 'B':
+		return true
+	}
+	return false
+}
+
+func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	} else {
-		return l.input[l.readPosition]
+		ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+		return ch
+	}
+}
+
+// peekSecondChar returns the character after the one peekChar returns.
+func (l *Lexer) peekSecondChar() rune {
+	if l.readPosition >= len(l.input) {
+		return 0
+	}
+	_, width := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	if l.readPosition+width >= len(l.input) {
+		return 0
+	}
+	ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition+width:])
+	return ch
+}
+
+// illegal returns an ILLEGAL token. Its literal is the error message, so
+// the parser can report what went wrong at the token's position.
+func (l *Lexer) illegal(format string, a ...interface{}) token.Token {
+	return token.Token{Type: token.ILLEGAL, Literal: fmt.Sprintf(format, a...)}
+}
+
+// readString reads a double quoted string and decodes its escape
+// sequences. The literal of the returned token is the decoded value.
+//
+// Strings are read up to the closing quote or up to an interpolation
+// "${". In the latter case the expression is lexed as regular tokens and
+// the "}" closing it resumes the string. first tells whether l.ch is the
+// opening quote or the "}" of an interpolation.
+func (l *Lexer) readString(first bool) token.Token {
+	var out strings.Builder
+	var err string
+
+	for {
+		l.readChar()
+		switch l.ch {
+		case 0:
+			return l.illegal("unterminated string literal")
+		case '\n':
+			return l.illegal("unterminated string literal")
+		case '"':
+			if err != "" {
+				return l.illegal("%s", err)
+			}
+			if first {
+				return token.Token{Type: token.STRING, Literal: out.String()}
+			}
+			return token.Token{Type: token.INTERP_END, Literal: out.String()}
+		case '$':
+			if l.peekChar() != '{' {
+				out.WriteRune(l.ch)
+				continue
+			}
+			l.readChar()
+			l.interpolations = append(l.interpolations, 0)
+			if err != "" {
+				return l.illegal("%s", err)
+			}
+			if first {
+				return token.Token{Type: token.INTERP_START, Literal: out.String()}
+			}
+			return token.Token{Type: token.INTERP_MID, Literal: out.String()}
+		case '\\':
+			l.readChar()
+			if msg := l.readEscape(&out); msg != "" && err == "" {
+				err = msg
+			}
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// readEscape decodes the escape sequence whose first character, after
+// the backslash, is l.ch. It returns an error message if it is invalid.
+func (l *Lexer) readEscape(out *strings.Builder) string {
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '0':
+		out.WriteByte(0)
+	case 'a':
+		out.WriteByte('\a')
+	case 'b':
+		out.WriteByte('\b')
+	case 'f':
+		out.WriteByte('\f')
+	case 'v':
+		out.WriteByte('\v')
+	case '\\', '"', '\'', '$':
+		out.WriteRune(l.ch)
+	case 'x':
+		digits := l.readHexDigits(2)
+		value, err := strconv.ParseUint(digits, 16, 8)
+		if err != nil {
+			return fmt.Sprintf("invalid escape sequence \\x%s", digits)
+		}
+		out.WriteByte(byte(value))
+	case 'u', 'U':
+		size := 4
+		if l.ch == 'U' {
+			size = 8
+		}
+		prefix := string(l.ch)
+		var digits string
+		if l.peekChar() == '{' {
+			l.readChar()
+			digits = l.readHexDigits(-1)
+			if l.peekChar() != '}' {
+				return fmt.Sprintf("invalid escape sequence \\%s{%s", prefix, digits)
+			}
+			l.readChar()
+		} else {
+			digits = l.readHexDigits(size)
+			if len(digits) != size {
+				return fmt.Sprintf("invalid escape sequence \\%s%s", prefix, digits)
+			}
+		}
+		value, err := strconv.ParseUint(digits, 16, 32)
+		if err != nil || !utf8.ValidRune(rune(value)) {
+			return fmt.Sprintf("invalid unicode code point \\%s%s", prefix, digits)
+		}
+		out.WriteRune(rune(value))
+	case 0:
+		return "unterminated string literal"
+	default:
+		return fmt.Sprintf("unknown escape sequence \\%c", l.ch)
+	}
+	return ""
+}
+
+// readHexDigits reads up to max hex digits following l.ch, or as many as
+// there are if max is negative.
+func (l *Lexer) readHexDigits(max int) string {
+	position := l.readPosition
+	for n := 0; n != max && isHexDigit(l.peekChar()); n++ {
+		l.readChar()
 	}
+	return l.input[position:l.readPosition]
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
 }
 
-func (l *Lexer) readString() string {
+// readRawString reads a backtick string. Raw strings have no escape
+// sequences and may span multiple lines.
+func (l *Lexer) readRawString() token.Token {
 	position := l.position + 1
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
+		if l.ch == 0 {
+			return l.illegal("unterminated raw string literal")
+		}
+		if l.ch == '`' {
 			break
 		}
 	}
-	return l.input[position:l.position]
+	// Carriage returns are dropped so files behave the same on every OS.
+	literal := strings.ReplaceAll(l.input[position:l.position], "\r", "")
+	return token.Token{Type: token.STRING, Literal: literal}
+}
+
+// readLineComment reads a "//" or "#" comment up to the end of the line.
+func (l *Lexer) readLineComment() token.Token {
+	position := l.position
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	return token.Token{Type: token.COMMENT, Literal: l.input[position:l.position]}
+}
+
+// readBlockComment reads a "/* */" comment. Block comments nest, so
+// commenting out code that already has one keeps working.
+func (l *Lexer) readBlockComment() token.Token {
+	position := l.position
+	depth := 0
+	for {
+		switch {
+		case l.ch == 0:
+			return l.illegal("unterminated block comment")
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
+		l.readChar()
+		if depth == 0 {
+			return token.Token{Type: token.COMMENT, Literal: l.input[position:l.position]}
+		}
+	}
 }
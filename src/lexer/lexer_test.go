@@ -15,7 +15,7 @@ def add = func(x, y) {
 };
 
 def result = add(five, ten);
-!-/*5;
+!-/ *5;
 5 < 10 > 5;
 
 if (5 < 10) {
@@ -148,3 +148,467 @@ if (5 < 10) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `def x = 10;
+  x >= "ab";`
+
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Position
+		expectedEnd  token.Position
+	}{
+		{token.DEF, token.Position{Line: 1, Column: 1, Offset: 0}, token.Position{Line: 1, Column: 4, Offset: 3}},
+		{token.IDENT, token.Position{Line: 1, Column: 5, Offset: 4}, token.Position{Line: 1, Column: 6, Offset: 5}},
+		{token.ASSIGN, token.Position{Line: 1, Column: 7, Offset: 6}, token.Position{Line: 1, Column: 8, Offset: 7}},
+		{token.INT, token.Position{Line: 1, Column: 9, Offset: 8}, token.Position{Line: 1, Column: 11, Offset: 10}},
+		{token.SEMICOLON, token.Position{Line: 1, Column: 11, Offset: 10}, token.Position{Line: 1, Column: 12, Offset: 11}},
+		{token.IDENT, token.Position{Line: 2, Column: 3, Offset: 14}, token.Position{Line: 2, Column: 4, Offset: 15}},
+		{token.GTE, token.Position{Line: 2, Column: 5, Offset: 16}, token.Position{Line: 2, Column: 7, Offset: 18}},
+		{token.STRING, token.Position{Line: 2, Column: 8, Offset: 19}, token.Position{Line: 2, Column: 12, Offset: 23}},
+		{token.SEMICOLON, token.Position{Line: 2, Column: 12, Offset: 23}, token.Position{Line: 2, Column: 13, Offset: 24}},
+		{token.EOF, token.Position{Line: 2, Column: 13, Offset: 24}, token.Position{Line: 2, Column: 14, Offset: 24}},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Pos != tt.expectedPos {
+			t.Errorf("tests[%d] - pos wrong. expected=%+v, got=%+v",
+				i, tt.expectedPos, tok.Pos)
+		}
+
+		if tok.End != tt.expectedEnd {
+			t.Errorf("tests[%d] - end wrong. expected=%+v, got=%+v",
+				i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `#!/usr/bin/env rizzy
+def a = 1; // trailing
+# hash comment
+/* block /* nested */ still comment */ a / 2;
+/* unterminated`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+	}{
+		{token.COMMENT, "#!/usr/bin/env rizzy", 1},
+		{token.DEF, "def", 2},
+		{token.IDENT, "a", 2},
+		{token.ASSIGN, "=", 2},
+		{token.INT, "1", 2},
+		{token.SEMICOLON, ";", 2},
+		{token.COMMENT, "// trailing", 2},
+		{token.COMMENT, "# hash comment", 3},
+		{token.COMMENT, "/* block /* nested */ still comment */", 4},
+		{token.IDENT, "a", 4},
+		{token.SLASH, "/", 4},
+		{token.INT, "2", 4},
+		{token.SEMICOLON, ";", 4},
+		{token.ILLEGAL, "unterminated block comment", 5},
+		{token.EOF, "", 5},
+	}
+
+	l := New(input)
+	l.KeepComments(true)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Line != tt.expectedLine {
+			t.Fatalf("tests[%d] - line wrong. expected=%d, got=%d",
+				i, tt.expectedLine, tok.Pos.Line)
+		}
+	}
+
+	l = New(input)
+	for _, tt := range tests {
+		if tt.expectedType == token.COMMENT {
+			continue
+		}
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("comments not skipped. expected=%q, got=%q",
+				tt.expectedType, tok.Type)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{`"a\"b"`, token.STRING, `a"b`},
+		{`"tab\tnew\nline\\"`, token.STRING, "tab\tnew\nline\\"},
+		{`"é\u{1F600}\x41"`, token.STRING, "é😀A"},
+		{`"\q"`, token.ILLEGAL, `unknown escape sequence \q`},
+		{`"\u12"`, token.ILLEGAL, `invalid escape sequence \u12`},
+		{`"abc`, token.ILLEGAL, "unterminated string literal"},
+		{"`raw \\n \"string\"\r\nline`", token.STRING, "raw \\n \"string\"\nline"},
+		{"`abc", token.ILLEGAL, "unterminated raw string literal"},
+	}
+
+	for i, tt := range tests {
+		l := New("  " + tt.input + ";")
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Column != 3 {
+			t.Fatalf("tests[%d] - column wrong. expected=3, got=%d",
+				i, tok.Pos.Column)
+		}
+	}
+}
+
+func TestInterpolatedStrings(t *testing.T) {
+	input := `"a ${x} b ${m["k"] + {1: 2}[1]} \${c}";`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INTERP_START, "a "},
+		{token.IDENT, "x"},
+		{token.INTERP_MID, " b "},
+		{token.IDENT, "m"},
+		{token.LBRACKET, "["},
+		{token.STRING, "k"},
+		{token.RBRACKET, "]"},
+		{token.PLUS, "+"},
+		{token.LBRACE, "{"},
+		{token.INT, "1"},
+		{token.COLON, ":"},
+		{token.INT, "2"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.INT, "1"},
+		{token.RBRACKET, "]"},
+		{token.INTERP_END, " ${c}"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	input := `0xFF 0b1010 0o755 1_000_000 1.5e-3 2E10 10. 3.25 0b102 12abc 123n 0xFFn 1.50d 2e3d 7d 0x1d`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "0xFF"},
+		{token.INT, "0b1010"},
+		{token.INT, "0o755"},
+		{token.INT, "1_000_000"},
+		{token.FLOAT, "1.5e-3"},
+		{token.FLOAT, "2E10"},
+		{token.FLOAT, "10."},
+		{token.FLOAT, "3.25"},
+		{token.INT, "0b102"},
+		{token.INT, "12abc"},
+		{token.BIGINT, "123n"},
+		{token.BIGINT, "0xFFn"},
+		{token.DECIMAL, "1.50d"},
+		{token.DECIMAL, "2e3d"},
+		{token.DECIMAL, "7d"},
+		{token.INT, "0x1d"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := `def größe = "häß"; 名前 + var1;`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.DEF, "def", 1},
+		{token.IDENT, "größe", 5},
+		{token.ASSIGN, "=", 11},
+		{token.STRING, "häß", 13},
+		{token.SEMICOLON, ";", 18},
+		{token.IDENT, "名前", 20},
+		{token.PLUS, "+", 23},
+		{token.IDENT, "var1", 25},
+		{token.SEMICOLON, ";", 29},
+		{token.EOF, "", 30},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - column wrong. expected=%d, got=%d",
+				i, tt.expectedColumn, tok.Pos.Column)
+		}
+	}
+}
+
+func TestAssignOperators(t *testing.T) {
+	input := `x = 1; x += 2; x -= 3; x *= 4; x /= 5;`
+
+	expected := []token.TokenType{
+		token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON,
+		token.IDENT, token.PLUS_ASSIGN, token.INT, token.SEMICOLON,
+		token.IDENT, token.MINUS_ASSIGN, token.INT, token.SEMICOLON,
+		token.IDENT, token.ASTERISK_ASSIGN, token.INT, token.SEMICOLON,
+		token.IDENT, token.SLASH_ASSIGN, token.INT, token.SEMICOLON,
+		token.EOF,
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt, tok.Type)
+		}
+	}
+}
+
+func TestLogicalOperatorTokens(t *testing.T) {
+	input := `a ?? b || c && d?.e?[f] ? g`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.NULLISH, "??"},
+		{token.IDENT, "b"},
+		{token.OR, "||"},
+		{token.IDENT, "c"},
+		{token.AND, "&&"},
+		{token.IDENT, "d"},
+		{token.OPT_DOT, "?."},
+		{token.IDENT, "e"},
+		{token.OPT_LBRACKET, "?["},
+		{token.IDENT, "f"},
+		{token.RBRACKET, "]"},
+		{token.QUESTION, "?"},
+		{token.IDENT, "g"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNullKeywords(t *testing.T) {
+	input := `x is not null; nullable`
+
+	expected := []token.TokenType{
+		token.IDENT, token.IS, token.NOT, token.NULL, token.SEMICOLON,
+		token.IDENT, token.EOF,
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt, tok.Type)
+		}
+	}
+}
+
+func TestStructTokens(t *testing.T) {
+	input := `struct Point { x, y } p.x = 1.5 impl Point {}`
+
+	expected := []token.TokenType{
+		token.STRUCT, token.IDENT, token.LBRACE, token.IDENT, token.COMMA,
+		token.IDENT, token.RBRACE, token.IDENT, token.DOT, token.IDENT,
+		token.ASSIGN, token.FLOAT, token.IMPL, token.IDENT, token.LBRACE,
+		token.RBRACE, token.EOF,
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt, tok.Type)
+		}
+	}
+}
+
+func TestArithmeticOperatorTokens(t *testing.T) {
+	input := `a % b ** c ^ d << e >> f * g`
+
+	expected := []token.TokenType{
+		token.IDENT, token.PERCENT, token.IDENT, token.POWER, token.IDENT,
+		token.BIT_XOR, token.IDENT, token.SHL, token.IDENT, token.SHR,
+		token.IDENT, token.ASTERISK, token.IDENT, token.EOF,
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt, tok.Type)
+		}
+	}
+}
+
+func TestMatchTokens(t *testing.T) {
+	input := `enum E { A(x) } match (n) { 1..5 => a, [h, ..t] => b, 1.5 => c }`
+
+	expected := []token.TokenType{
+		token.ENUM, token.IDENT, token.LBRACE, token.IDENT, token.LPAREN,
+		token.IDENT, token.RPAREN, token.RBRACE, token.MATCH, token.LPAREN,
+		token.IDENT, token.RPAREN, token.LBRACE, token.INT, token.DOTDOT,
+		token.INT, token.ARROW, token.IDENT, token.COMMA, token.LBRACKET,
+		token.IDENT, token.COMMA, token.DOTDOT, token.IDENT, token.RBRACKET,
+		token.ARROW, token.IDENT, token.COMMA, token.FLOAT, token.ARROW,
+		token.IDENT, token.RBRACE, token.EOF,
+	}
+
+	l := New(input)
+
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt, tok.Type)
+		}
+	}
+}
+
+func TestConditionalTokens(t *testing.T) {
+	input := `c ?.5 : 1 ?.x ? [y] : .25 ?[z]`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "c"},
+		{token.QUESTION, "?"},
+		{token.FLOAT, ".5"},
+		{token.COLON, ":"},
+		{token.INT, "1"},
+		{token.OPT_DOT, "?."},
+		{token.IDENT, "x"},
+		{token.QUESTION, "?"},
+		{token.LBRACKET, "["},
+		{token.IDENT, "y"},
+		{token.RBRACKET, "]"},
+		{token.COLON, ":"},
+		{token.FLOAT, ".25"},
+		{token.OPT_LBRACKET, "?["},
+		{token.IDENT, "z"},
+		{token.RBRACKET, "]"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
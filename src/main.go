@@ -2,27 +2,36 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/user"
 
+	"github.com/batt0s/rizzy/evaluator"
 	"github.com/batt0s/rizzy/repl"
 )
 
 func main() {
+	checked := flag.Bool("checked", false, "report integer overflow as an error instead of switching to a BIGINT")
+	flag.Parse()
+
+	evaluator.CheckedArithmetic = *checked
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
 	}
 
-	cliArgs := os.Args
-	if len(cliArgs) > 1 {
-		filePath := cliArgs[1]
+	if flag.NArg() > 0 {
+		filePath := flag.Arg(0)
 		if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
 			fmt.Printf("Couldn't find file: %s\n", filePath)
 			os.Exit(1)
 		}
-		repl.RunFile(filePath, os.Stdout)
+		if err := repl.RunFile(filePath, os.Stdout); err != nil {
+			fmt.Printf("Couldn't run file: %s\n", err)
+			os.Exit(1)
+		}
 	} else {
 		fmt.Printf("Hello %s! This is the Rizzler!\n", user.Username)
 		repl.Start(os.Stdin, os.Stdout)
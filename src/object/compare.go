@@ -0,0 +1,283 @@
+package object
+
+import (
+	"math"
+	"math/big"
+	"strings"
+)
+
+// FloatNearlyEqual tells whether two floats are equal up to a relative
+// error, so 0.1 + 0.2 equals 0.3.
+func FloatNearlyEqual(f1, f2 float64) bool {
+	const epsilon = 0.0001
+	const min_float = math.SmallestNonzeroFloat64
+	const max_float = math.MaxFloat64
+
+	absf1 := math.Abs(f1)
+	absf2 := math.Abs(f2)
+	diff := math.Abs(f1 - f2)
+
+	if f1 == f2 {
+		return true
+	} else if f1 == 0 || f2 == 0 || absf1+absf2 < min_float {
+		return diff < (epsilon * min_float)
+	} else {
+		return diff/math.Min(absf1+absf2, max_float) < epsilon
+	}
+}
+
+// Equal tells whether a and b have the same value. Numbers are equal
+// when their values are, whatever their types (1 == 1.0), strings,
+// arrays, tuples, maps and sets when their contents are, and structs and
+// enum values of the same type and variant when their fields are. Other
+// objects are only equal to themselves.
+func Equal(a, b Object) bool {
+	return equal(a, b, nil)
+}
+
+// equal is Equal for objects inside the pairs of containers in seen. When
+// it comes back to a pair it is already comparing, the pair is taken to
+// be equal, and the rest of their elements decide.
+func equal(a, b Object, seen map[objectPair]bool) bool {
+	if c, ok := compareNumbers(a, b); ok {
+		if a.Type() == FLOAT_OBJ || b.Type() == FLOAT_OBJ {
+			return FloatNearlyEqual(float(a), float(b))
+		}
+		return c == 0
+	}
+
+	if isContainer(a) {
+		pair := objectPair{a, b}
+		if seen[pair] {
+			return true
+		}
+		if seen == nil {
+			seen = map[objectPair]bool{}
+		}
+		seen[pair] = true
+		defer delete(seen, pair)
+	}
+	eq := func(a, b Object) bool { return equal(a, b, seen) }
+
+	switch a := a.(type) {
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	case *Array:
+		b, ok := b.(*Array)
+		return ok && elementsEqual(a.Elements, b.Elements, eq)
+	case *Tuple:
+		b, ok := b.(*Tuple)
+		return ok && elementsEqual(a.Elements, b.Elements, eq)
+	case *Map:
+		b, ok := b.(*Map)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		for _, pair := range a.Pairs() {
+			other, ok := b.Get(pair.Key.(Hashable))
+			if !ok || !equal(pair.Value, other, seen) {
+				return false
+			}
+		}
+		return true
+	case *Struct:
+		b, ok := b.(*Struct)
+		return ok && a.Def == b.Def && elementsEqual(a.Values, b.Values, eq)
+	case *EnumValue:
+		b, ok := b.(*EnumValue)
+		return ok && a.Variant == b.Variant && elementsEqual(a.Values, b.Values, eq)
+	case *Set:
+		b, ok := b.(*Set)
+		return ok && a.Len() == b.Len() && a.IsSubset(b)
+	}
+
+	return a == b
+}
+
+// keyEqual tells whether a and b are the same map key. It is Equal,
+// except that floats have to be exactly equal, as nearly equal floats
+// don't have the same HashKey.
+func keyEqual(a, b Object) bool {
+	if a.Type() == FLOAT_OBJ || b.Type() == FLOAT_OBJ {
+		if a, ok := a.(*Float); ok {
+			if b, ok := b.(*Float); ok {
+				return a.Value == b.Value
+			}
+		}
+		x, ok := exactValue(a)
+		y, ok2 := exactValue(b)
+		return ok && ok2 && x.Cmp(y) == 0
+	}
+
+	switch a := a.(type) {
+	case *Array:
+		b, ok := b.(*Array)
+		return ok && elementsEqual(a.Elements, b.Elements, keyEqual)
+	case *Tuple:
+		b, ok := b.(*Tuple)
+		return ok && elementsEqual(a.Elements, b.Elements, keyEqual)
+	}
+
+	return Equal(a, b)
+}
+
+func elementsEqual(a, b []Object, equal func(a, b Object) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare orders a and b and returns -1, 0 or +1. Numbers are ordered by
+// their exact value, strings byte by byte, arrays and tuples element by element, the
+// way words are ordered in a dictionary. ok is false if a and b can't be
+// ordered, like a number and a string.
+func Compare(a, b Object) (c int, ok bool) {
+	return compare(a, b, nil)
+}
+
+// compare is Compare for objects inside the pairs of containers in seen.
+// Like equal, it takes a pair it comes back to as equal.
+func compare(a, b Object, seen map[objectPair]bool) (int, bool) {
+	if c, ok := compareNumbers(a, b); ok {
+		return c, true
+	}
+
+	if isContainer(a) {
+		pair := objectPair{a, b}
+		if seen[pair] {
+			return 0, true
+		}
+		if seen == nil {
+			seen = map[objectPair]bool{}
+		}
+		seen[pair] = true
+		defer delete(seen, pair)
+	}
+
+	switch a := a.(type) {
+	case *String:
+		if b, ok := b.(*String); ok {
+			return strings.Compare(a.Value, b.Value), true
+		}
+	case *Array:
+		if b, ok := b.(*Array); ok {
+			return compareElements(a.Elements, b.Elements, seen)
+		}
+	case *Tuple:
+		if b, ok := b.(*Tuple); ok {
+			return compareElements(a.Elements, b.Elements, seen)
+		}
+	}
+
+	return 0, false
+}
+
+func compareElements(a, b []Object, seen map[objectPair]bool) (int, bool) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		c, ok := compare(a[i], b[i], seen)
+		if !ok {
+			return 0, false
+		}
+		if c != 0 {
+			return c, true
+		}
+	}
+	return compareInts(int64(len(a)), int64(len(b))), true
+}
+
+// compareNumbers orders two numbers. An INTEGER or BIGINT compared with a
+// FLOAT is converted to a FLOAT, compared with a DECIMAL to a DECIMAL.
+// FLOATs and DECIMALs can't be compared with each other. FLOATs are
+// ordered exactly, only Equal lets them be nearly equal, so that sorting
+// stays consistent.
+func compareNumbers(a, b Object) (int, bool) {
+	switch {
+	case a.Type() == INTEGER_OBJ && b.Type() == INTEGER_OBJ:
+		return compareInts(a.(*Integer).Value, b.(*Integer).Value), true
+	case isInteger(a) && isInteger(b):
+		return bigInt(a).Cmp(bigInt(b)), true
+	case a.Type() == FLOAT_OBJ && (isInteger(b) || b.Type() == FLOAT_OBJ),
+		b.Type() == FLOAT_OBJ && isInteger(a):
+		x, y := float(a), float(b)
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case a.Type() == DECIMAL_OBJ && (isInteger(b) || b.Type() == DECIMAL_OBJ),
+		b.Type() == DECIMAL_OBJ && isInteger(a):
+		return decimal(a).Cmp(decimal(b)), true
+	}
+	return 0, false
+}
+
+func compareInts(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func isInteger(obj Object) bool {
+	return obj.Type() == INTEGER_OBJ || obj.Type() == BIGINT_OBJ
+}
+
+func bigInt(obj Object) *big.Int {
+	if i, ok := obj.(*Integer); ok {
+		return big.NewInt(i.Value)
+	}
+	return obj.(*BigInt).Value
+}
+
+func float(obj Object) float64 {
+	if f, ok := obj.(*Float); ok {
+		return f.Value
+	}
+	f, _ := new(big.Float).SetInt(bigInt(obj)).Float64()
+	return f
+}
+
+// exactValue returns the exact value of a FLOAT, INTEGER, BIGINT or
+// DECIMAL. ok is false for NaN and infinities, which aren't equal to any
+// of these but a FLOAT.
+func exactValue(obj Object) (r *big.Rat, ok bool) {
+	switch obj := obj.(type) {
+	case *Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(obj.Value), true
+	case *Integer, *BigInt:
+		return new(big.Rat).SetInt(bigInt(obj)), true
+	case *Decimal:
+		scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(obj.Scale)), nil)
+		return new(big.Rat).SetFrac(obj.Unscaled, scale), true
+	}
+	return nil, false
+}
+
+func decimal(obj Object) *Decimal {
+	if d, ok := obj.(*Decimal); ok {
+		return d
+	}
+	return NewDecimal(bigInt(obj))
+}
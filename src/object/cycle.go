@@ -0,0 +1,49 @@
+package object
+
+// Arrays, maps and structs can be changed after they are made, so they
+// can end up holding themselves: a[0] = a. Printing, comparing and
+// hashing go through the objects inside a container, and keep track of
+// the containers they are in to stop at one they have seen before.
+
+// container is an object that holds other objects.
+type container interface {
+	Object
+
+	// inspect writes the container, with inspectIn for the objects in it.
+	inspect(seen map[Object]bool) string
+}
+
+// inspectIn is Inspect for an object inside the containers in seen. A
+// container that holds itself is written as [...], like Python does.
+func inspectIn(obj Object, seen map[Object]bool) string {
+	c, ok := obj.(container)
+	if !ok {
+		return obj.Inspect()
+	}
+	if seen[c] {
+		switch c := c.(type) {
+		case *Array:
+			return "[...]"
+		case *Tuple:
+			return "(...)"
+		case *Map:
+			return "{...}"
+		case *Struct:
+			return c.Def.Name + "{...}"
+		default:
+			return "..."
+		}
+	}
+
+	seen[c] = true
+	defer delete(seen, c)
+	return c.inspect(seen)
+}
+
+func isContainer(obj Object) bool {
+	_, ok := obj.(container)
+	return ok
+}
+
+// objectPair is two containers that are being compared.
+type objectPair struct{ a, b Object }
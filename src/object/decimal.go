@@ -0,0 +1,344 @@
+package object
+
+import (
+	"hash/fnv"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, Unscaled × 10^-Scale. 1.50 is
+// stored as Unscaled 150 and Scale 2. Scale is never negative.
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int
+}
+
+// RoundingMode tells how a Decimal is rounded when it has more digits
+// than the precision of a DecimalContext.
+type RoundingMode int
+
+const (
+	RoundHalfEven RoundingMode = iota // to nearest, ties to even
+	RoundHalfUp                       // to nearest, ties away from zero
+	RoundHalfDown                     // to nearest, ties towards zero
+	RoundUp                           // away from zero
+	RoundDown                         // towards zero
+	RoundCeiling                      // towards +infinity
+	RoundFloor                        // towards -infinity
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	RoundHalfEven: "half_even",
+	RoundHalfUp:   "half_up",
+	RoundHalfDown: "half_down",
+	RoundUp:       "up",
+	RoundDown:     "down",
+	RoundCeiling:  "ceiling",
+	RoundFloor:    "floor",
+}
+
+func (m RoundingMode) String() string {
+	return roundingModeNames[m]
+}
+
+// ParseRoundingMode returns the rounding mode with the given name, like
+// "half_even" or "floor".
+func ParseRoundingMode(name string) (RoundingMode, bool) {
+	for mode, modeName := range roundingModeNames {
+		if modeName == name {
+			return mode, true
+		}
+	}
+	return 0, false
+}
+
+// DecimalContext controls the results of Decimal arithmetic. Results are
+// rounded to Precision significant digits, but digits before the
+// decimal point are never rounded away.
+type DecimalContext struct {
+	Precision int
+	Rounding  RoundingMode
+}
+
+var DefaultDecimalContext = DecimalContext{Precision: 28, Rounding: RoundHalfEven}
+
+// maxDecimalExponent bounds the exponent of a parsed decimal, so "1e999999999"
+// doesn't allocate a billion digits.
+const maxDecimalExponent = 100000
+
+var bigOne = big.NewInt(1)
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// digitCount returns the number of decimal digits of x, ignoring its sign.
+func digitCount(x *big.Int) int {
+	return len(new(big.Int).Abs(x).String())
+}
+
+// NewDecimal returns the Decimal with the value of the integer i.
+func NewDecimal(i *big.Int) *Decimal {
+	return &Decimal{Unscaled: new(big.Int).Set(i)}
+}
+
+// ParseDecimal parses a decimal number such as "-12.50", "1_000" or
+// "2.5e-3". Unlike a float, the result holds exactly the given digits.
+func ParseDecimal(s string) (*Decimal, bool) {
+	s = strings.ReplaceAll(s, "_", "")
+
+	negative := false
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil || e > maxDecimalExponent || e < -maxDecimalExponent {
+			return nil, false
+		}
+		mantissa, exponent = s[:i], e
+	}
+
+	intPart, fraction, _ := strings.Cut(mantissa, ".")
+	digits := intPart + fraction
+	if digits == "" {
+		return nil, false
+	}
+	for _, ch := range digits {
+		if ch < '0' || ch > '9' {
+			return nil, false
+		}
+	}
+
+	unscaled, _ := new(big.Int).SetString(digits, 10)
+	scale := len(fraction) - exponent
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	if negative {
+		unscaled.Neg(unscaled)
+	}
+
+	return &Decimal{Unscaled: unscaled, Scale: scale}, true
+}
+
+func (d *Decimal) Type() ObjectType { return DECIMAL_OBJ }
+func (d *Decimal) Inspect() string {
+	digits := new(big.Int).Abs(d.Unscaled).String()
+	if d.Scale > 0 {
+		if len(digits) <= d.Scale {
+			digits = strings.Repeat("0", d.Scale-len(digits)+1) + digits
+		}
+		point := len(digits) - d.Scale
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if d.Unscaled.Sign() < 0 {
+		digits = "-" + digits
+	}
+	return digits
+}
+
+// HashKey of a Decimal with an integer value is the same as the integer's,
+// so 2, 2n and 2.00d are the same map key, and that of a Decimal a FLOAT
+// can hold exactly is the same as the FLOAT's, so 0.5d and 0.5 are too.
+func (d *Decimal) HashKey() HashKey {
+	t := d.trim(0)
+	if t.Scale == 0 {
+		return (&BigInt{Value: t.Unscaled}).HashKey()
+	}
+	if r, _ := exactValue(t); r != nil {
+		if f, exact := r.Float64(); exact {
+			return (&Float{Value: f}).HashKey()
+		}
+	}
+	h := fnv.New64a()
+	h.Write([]byte(t.Inspect()))
+	return HashKey{
+		Type:  d.Type(),
+		Value: h.Sum64(),
+	}
+}
+
+// trim drops trailing zeros after the decimal point, keeping at least
+// minScale digits after it.
+func (d *Decimal) trim(minScale int) *Decimal {
+	unscaled, scale := new(big.Int).Set(d.Unscaled), d.Scale
+	ten, digit := big.NewInt(10), new(big.Int)
+	for scale > minScale {
+		q, r := new(big.Int).QuoRem(unscaled, ten, digit)
+		if r.Sign() != 0 {
+			break
+		}
+		unscaled, scale = q, scale-1
+	}
+	return &Decimal{Unscaled: unscaled, Scale: scale}
+}
+
+// align returns the unscaled values of a and b brought to the same scale.
+func align(a, b *Decimal) (*big.Int, *big.Int, int) {
+	x, y := a.Unscaled, b.Unscaled
+	switch {
+	case a.Scale < b.Scale:
+		x = new(big.Int).Mul(x, pow10(b.Scale-a.Scale))
+		return x, y, b.Scale
+	case a.Scale > b.Scale:
+		y = new(big.Int).Mul(y, pow10(a.Scale-b.Scale))
+	}
+	return x, y, a.Scale
+}
+
+func (d *Decimal) Sign() int { return d.Unscaled.Sign() }
+
+// Cmp compares d and o and returns -1, 0 or +1.
+func (d *Decimal) Cmp(o *Decimal) int {
+	x, y, _ := align(d, o)
+	return x.Cmp(y)
+}
+
+func (d *Decimal) Neg() *Decimal {
+	return &Decimal{Unscaled: new(big.Int).Neg(d.Unscaled), Scale: d.Scale}
+}
+
+func (d *Decimal) Add(o *Decimal, ctx DecimalContext) *Decimal {
+	x, y, scale := align(d, o)
+	return ctx.Round(&Decimal{Unscaled: new(big.Int).Add(x, y), Scale: scale})
+}
+
+func (d *Decimal) Sub(o *Decimal, ctx DecimalContext) *Decimal {
+	x, y, scale := align(d, o)
+	return ctx.Round(&Decimal{Unscaled: new(big.Int).Sub(x, y), Scale: scale})
+}
+
+func (d *Decimal) Mul(o *Decimal, ctx DecimalContext) *Decimal {
+	unscaled := new(big.Int).Mul(d.Unscaled, o.Unscaled)
+	return ctx.Round(&Decimal{Unscaled: unscaled, Scale: d.Scale + o.Scale})
+}
+
+// Quo returns d / o rounded to the precision of ctx. o must not be zero.
+// An exact quotient keeps no more digits than it needs, so 1.50 / 3 is
+// 0.50 and 10 / 4 is 2.5.
+func (d *Decimal) Quo(o *Decimal, ctx DecimalContext) *Decimal {
+	// Scale the dividend so the quotient gets a digit more than the
+	// precision, which is the one rounding looks at.
+	shift := ctx.Precision + digitCount(o.Unscaled) - digitCount(d.Unscaled) + 1
+	if shift < 0 {
+		shift = 0
+	}
+	dividend := new(big.Int).Mul(d.Unscaled, pow10(shift))
+	q, r := new(big.Int).QuoRem(dividend, o.Unscaled, new(big.Int))
+	scale := d.Scale - o.Scale + shift
+
+	if r.Sign() == 0 {
+		minScale := d.Scale - o.Scale
+		if minScale < 0 {
+			minScale = 0
+		}
+		return ctx.Round(normalize(q, scale).trim(minScale))
+	}
+
+	// The quotient isn't exact: append a 1 so that a quotient ending in
+	// 5 isn't mistaken for a tie.
+	q.Mul(q, big.NewInt(10))
+	if dividend.Sign()*o.Unscaled.Sign() < 0 {
+		q.Sub(q, bigOne)
+	} else {
+		q.Add(q, bigOne)
+	}
+	return ctx.Round(normalize(q, scale+1))
+}
+
+// Rem returns the remainder of d / o with the quotient truncated
+// towards zero, like % on integers. o must not be zero.
+func (d *Decimal) Rem(o *Decimal, ctx DecimalContext) *Decimal {
+	x, y, scale := align(d, o)
+	return ctx.Round(&Decimal{Unscaled: new(big.Int).Rem(x, y), Scale: scale})
+}
+
+// Pow returns d ** n. d must not be zero if n is negative. Pow does not
+// bound the result: its digits and scale grow with |n|.
+func (d *Decimal) Pow(n int64, ctx DecimalContext) *Decimal {
+	if n < 0 {
+		return NewDecimal(bigOne).Quo(d.Pow(-n, ctx), ctx)
+	}
+	unscaled := new(big.Int).Exp(d.Unscaled, big.NewInt(n), nil)
+	return ctx.Round(&Decimal{Unscaled: unscaled, Scale: d.Scale * int(n)})
+}
+
+// IsInteger tells whether d has no fractional part.
+func (d *Decimal) IsInteger() bool {
+	return new(big.Int).Rem(d.Unscaled, pow10(d.Scale)).Sign() == 0
+}
+
+// Int returns the integer part of d.
+func (d *Decimal) Int() *big.Int {
+	return new(big.Int).Quo(d.Unscaled, pow10(d.Scale))
+}
+
+// Float64 returns the float64 nearest to d.
+func (d *Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.Inspect(), 64)
+	return f
+}
+
+// normalize makes a Decimal out of unscaled × 10^-scale, where scale may
+// be negative.
+func normalize(unscaled *big.Int, scale int) *Decimal {
+	if scale < 0 {
+		return &Decimal{Unscaled: unscaled.Mul(unscaled, pow10(-scale))}
+	}
+	return &Decimal{Unscaled: unscaled, Scale: scale}
+}
+
+// Round rounds d to the precision of the context.
+func (c DecimalContext) Round(d *Decimal) *Decimal {
+	drop := digitCount(d.Unscaled) - c.Precision
+	if drop > d.Scale {
+		drop = d.Scale
+	}
+	if c.Precision <= 0 || drop <= 0 {
+		return d
+	}
+
+	negative := d.Unscaled.Sign() < 0
+	divisor := pow10(drop)
+	q, r := new(big.Int).QuoRem(new(big.Int).Abs(d.Unscaled), divisor, new(big.Int))
+	if c.roundsUp(q, r, divisor, negative) {
+		q.Add(q, bigOne)
+	}
+	if negative {
+		q.Neg(q)
+	}
+
+	return &Decimal{Unscaled: q, Scale: d.Scale - drop}
+}
+
+// roundsUp tells whether the magnitude q with the dropped digits r out of
+// divisor has to be rounded up.
+func (c DecimalContext) roundsUp(q, r, divisor *big.Int, negative bool) bool {
+	if r.Sign() == 0 {
+		return false
+	}
+
+	half := new(big.Int).Lsh(r, 1).Cmp(divisor)
+	switch c.Rounding {
+	case RoundUp:
+		return true
+	case RoundDown:
+		return false
+	case RoundCeiling:
+		return !negative
+	case RoundFloor:
+		return negative
+	case RoundHalfUp:
+		return half >= 0
+	case RoundHalfDown:
+		return half > 0
+	default:
+		return half > 0 || half == 0 && q.Bit(0) == 1
+	}
+}
@@ -0,0 +1,94 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// EnumType is a type declared with `enum Shape { Circle(r), Empty }`. Its
+// variants are read with a dot, Shape.Circle.
+type EnumType struct {
+	Name     string
+	Variants []*EnumVariant
+}
+
+func (et *EnumType) Type() ObjectType { return ENUM_OBJ }
+func (et *EnumType) Inspect() string {
+	variants := []string{}
+	for _, v := range et.Variants {
+		variants = append(variants, v.signature())
+	}
+	return fmt.Sprintf("enum %s { %s }", et.Name, strings.Join(variants, ", "))
+}
+
+// Variant returns the variant with the given name.
+func (et *EnumType) Variant(name string) (*EnumVariant, bool) {
+	for _, v := range et.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// EnumVariant is a variant of an enum. Calling a variant with fields, with
+// a value for each field, makes an EnumValue. A variant without fields has
+// a single value, Unit.
+type EnumVariant struct {
+	Enum   *EnumType
+	Name   string
+	Fields []string
+	Unit   *EnumValue // nil for a variant with fields
+}
+
+func (ev *EnumVariant) Type() ObjectType { return VARIANT_OBJ }
+func (ev *EnumVariant) Inspect() string {
+	return "variant " + ev.Enum.Name + "." + ev.signature()
+}
+
+func (ev *EnumVariant) signature() string {
+	if len(ev.Fields) == 0 {
+		return ev.Name
+	}
+	return ev.Name + "(" + strings.Join(ev.Fields, ", ") + ")"
+}
+
+// EnumValue is a value of an enum. Its type is the name of the enum, so
+// Shape.Circle(1) has type "Shape".
+type EnumValue struct {
+	Variant *EnumVariant
+	Values  []Object // in the order of Variant.Fields
+}
+
+func (ev *EnumValue) Type() ObjectType { return ObjectType(ev.Variant.Enum.Name) }
+func (ev *EnumValue) Inspect() string  { return inspectIn(ev, map[Object]bool{}) }
+func (ev *EnumValue) inspect(seen map[Object]bool) string {
+	var out bytes.Buffer
+
+	out.WriteString(ev.Variant.Enum.Name)
+	out.WriteString(".")
+	out.WriteString(ev.Variant.Name)
+
+	if len(ev.Variant.Fields) > 0 {
+		values := []string{}
+		for _, v := range ev.Values {
+			values = append(values, inspectIn(v, seen))
+		}
+		out.WriteString("(")
+		out.WriteString(strings.Join(values, ", "))
+		out.WriteString(")")
+	}
+
+	return out.String()
+}
+
+// Get returns the value of a field of the variant.
+func (ev *EnumValue) Get(field string) (Object, bool) {
+	for i, f := range ev.Variant.Fields {
+		if f == field {
+			return ev.Values[i], true
+		}
+	}
+	return nil, false
+}
@@ -23,6 +23,18 @@ func (e *Environment) Set(name string, val Object) Object {
 	return val
 }
 
+// Assign updates the nearest existing binding of name, walking up the
+// enclosing environments. It reports false if name is not defined.
+func (e *Environment) Assign(name string, val Object) (Object, bool) {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			env.store[name] = val
+			return val, true
+		}
+	}
+	return nil, false
+}
+
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
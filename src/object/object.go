@@ -2,11 +2,16 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 
 	"github.com/batt0s/rizzy/ast"
+	"github.com/batt0s/rizzy/token"
 )
 
 type ObjectType string
@@ -18,18 +23,44 @@ type Object interface {
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	BIGINT_OBJ       = "BIGINT"
 	FLOAT_OBJ        = "FLOAT"
+	DECIMAL_OBJ      = "DECIMAL"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	STRING_OBJ       = "STRING"
 	ARRAY_OBJ        = "ARRAY"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	ERROR_OBJ        = "ERROR"
 	FUNCTION_OBJ     = "FUNCTION"
 	BUILTIN_OBJ      = "BUILTIN"
 	MAP_OBJ          = "MAP"
+	SET_OBJ          = "SET"
+	TUPLE_OBJ        = "TUPLE"
+	STRUCT_TYPE_OBJ  = "STRUCT"
+	METHOD_OBJ       = "METHOD"
+	ENUM_OBJ         = "ENUM"
+	VARIANT_OBJ      = "VARIANT"
 )
 
+var builtinTypes = map[ObjectType]bool{
+	INTEGER_OBJ: true, BIGINT_OBJ: true, FLOAT_OBJ: true, DECIMAL_OBJ: true,
+	BOOLEAN_OBJ: true, STRING_OBJ: true, ARRAY_OBJ: true, NULL_OBJ: true,
+	RETURN_VALUE_OBJ: true, BREAK_OBJ: true, CONTINUE_OBJ: true, ERROR_OBJ: true,
+	FUNCTION_OBJ: true, BUILTIN_OBJ: true, MAP_OBJ: true, SET_OBJ: true,
+	TUPLE_OBJ: true, STRUCT_TYPE_OBJ: true, METHOD_OBJ: true, ENUM_OBJ: true,
+	VARIANT_OBJ: true,
+}
+
+// IsBuiltinType tells whether name is the type of a builtin object. The
+// type of a struct or enum value is its name, so it can't be one of
+// these.
+func IsBuiltinType(name string) bool {
+	return builtinTypes[ObjectType(name)]
+}
+
 // Integer
 type Integer struct {
 	Value int64
@@ -42,6 +73,19 @@ func (i *Integer) Inspect() string {
 	return fmt.Sprintf("%d", i.Value)
 }
 
+// BigInt is an integer of any size. Integer arithmetic switches to it
+// when a result doesn't fit in an Integer.
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Type() ObjectType {
+	return BIGINT_OBJ
+}
+func (b *BigInt) Inspect() string {
+	return b.Value.String()
+}
+
 // Float
 type Float struct {
 	Value float64
@@ -50,8 +94,29 @@ type Float struct {
 func (f *Float) Type() ObjectType {
 	return FLOAT_OBJ
 }
+
+// Inspect returns the shortest representation that reads back as the
+// same float. Very small and very large floats use an exponent (1e-09,
+// 1e+16), others always have a decimal point (2.0, 0.1).
 func (f *Float) Inspect() string {
-	return fmt.Sprintf("%f", f.Value)
+	switch {
+	case math.IsNaN(f.Value):
+		return "nan"
+	case math.IsInf(f.Value, 1):
+		return "inf"
+	case math.IsInf(f.Value, -1):
+		return "-inf"
+	}
+
+	abs := math.Abs(f.Value)
+	if abs != 0 && (abs < 1e-4 || abs >= 1e16) {
+		return strconv.FormatFloat(f.Value, 'e', -1, 64)
+	}
+	s := strconv.FormatFloat(f.Value, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
 }
 
 // Boolean
@@ -88,9 +153,35 @@ func (rv *ReturnValue) Inspect() string {
 	return rv.Value.Inspect()
 }
 
+// Break and Continue carry a loop control statement up to the loop
+// that it applies to.
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+
 // Error
 type Error struct {
 	Message string
+
+	// Pos and End span the node that produced the error.
+	Pos token.Position
+	End token.Position
+
+	// Stack holds the function calls the error went through,
+	// innermost call first.
+	Stack []Frame
+}
+
+// Frame is a function call on the stack of an Error.
+type Frame struct {
+	Function string
+	Pos      token.Position // the call site
 }
 
 func (e *Error) Type() ObjectType {
@@ -102,6 +193,7 @@ func (e *Error) Inspect() string {
 
 // Function
 type Function struct {
+	Name       string // name it was first defined with, empty if anonymous
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
@@ -128,6 +220,19 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
+// BoundMethod is a method together with the value it was looked up on,
+// which the method gets as its first argument, self.
+type BoundMethod struct {
+	Receiver Object
+	Name     string
+	Method   Object // a *Function or a *Builtin
+}
+
+func (bm *BoundMethod) Type() ObjectType { return METHOD_OBJ }
+func (bm *BoundMethod) Inspect() string {
+	return fmt.Sprintf("method %s.%s", bm.Receiver.Type(), bm.Name)
+}
+
 // String
 type String struct {
 	Value string
@@ -149,15 +254,19 @@ func (b *Builtin) Inspect() string  { return "builtin function" }
 // Array
 type Array struct {
 	Elements []Object
+
+	// A frozen array can't be changed, and can be used as a map key.
+	Frozen bool
 }
 
 func (a *Array) Type() ObjectType { return ARRAY_OBJ }
-func (a *Array) Inspect() string {
+func (a *Array) Inspect() string  { return inspectIn(a, map[Object]bool{}) }
+func (a *Array) inspect(seen map[Object]bool) string {
 	var out bytes.Buffer
 
 	elements := []string{}
 	for _, e := range a.Elements {
-		elements = append(elements, e.Inspect())
+		elements = append(elements, inspectIn(e, seen))
 	}
 
 	out.WriteString("[")
@@ -167,6 +276,35 @@ func (a *Array) Inspect() string {
 	return out.String()
 }
 
+// Tuple is a sequence that can't be changed. A tuple can be used as a
+// map key if its elements can.
+type Tuple struct {
+	Elements []Object
+}
+
+func (t *Tuple) Type() ObjectType { return TUPLE_OBJ }
+
+// Inspect writes a tuple like a tuple literal, with a trailing comma if
+// it has a single element: (1, 2), (1,), ().
+func (t *Tuple) Inspect() string { return inspectIn(t, map[Object]bool{}) }
+func (t *Tuple) inspect(seen map[Object]bool) string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range t.Elements {
+		elements = append(elements, inspectIn(e, seen))
+	}
+
+	out.WriteString("(")
+	out.WriteString(strings.Join(elements, ", "))
+	if len(elements) == 1 {
+		out.WriteString(",")
+	}
+	out.WriteString(")")
+
+	return out.String()
+}
+
 // Hash Map
 type HashKey struct {
 	Type  ObjectType
@@ -195,6 +333,64 @@ func (i *Integer) HashKey() HashKey {
 	}
 }
 
+// HashKey of a BigInt that fits in an Integer is the same as the
+// Integer's, so 5 and 5n are the same map key.
+func (b *BigInt) HashKey() HashKey {
+	if b.Value.IsInt64() {
+		return (&Integer{Value: b.Value.Int64()}).HashKey()
+	}
+	h := fnv.New64a()
+	h.Write([]byte(b.Value.String()))
+	return HashKey{
+		Type:  b.Type(),
+		Value: h.Sum64(),
+	}
+}
+
+// HashKey of a Float with an integer value is the same as the integer's,
+// so 2.0 and 2 are the same map key.
+func (f *Float) HashKey() HashKey {
+	if f.Value == math.Trunc(f.Value) && !math.IsInf(f.Value, 0) {
+		i, _ := new(big.Float).SetFloat64(f.Value).Int(nil)
+		return (&BigInt{Value: i}).HashKey()
+	}
+	return HashKey{
+		Type:  f.Type(),
+		Value: math.Float64bits(f.Value),
+	}
+}
+
+func (n *Null) HashKey() HashKey {
+	return HashKey{Type: n.Type()}
+}
+
+// HashKey of an Array combines the hash keys of its elements, which must
+// all be Hashable. See AsHashable.
+func (a *Array) HashKey() HashKey {
+	return hashElements(a.Type(), a.Elements)
+}
+
+func (t *Tuple) HashKey() HashKey {
+	return hashElements(t.Type(), t.Elements)
+}
+
+// hashElements goes through the elements of an array or a tuple. They
+// hold no cycles, as AsHashable has checked.
+func hashElements(t ObjectType, elements []Object) HashKey {
+	h := fnv.New64a()
+	var value [8]byte
+	for _, e := range elements {
+		key := e.(Hashable).HashKey()
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(value[:], key.Value)
+		h.Write(value[:])
+	}
+	return HashKey{
+		Type:  t,
+		Value: h.Sum64(),
+	}
+}
+
 func (s *String) HashKey() HashKey {
 	h := fnv.New64a()
 	h.Write([]byte(s.Value))
@@ -209,18 +405,100 @@ type HashPair struct {
 	Value Object
 }
 
+// Map is a hash map that remembers the order its keys were added in.
+// Printing or iterating over a map goes through the keys in that order.
+//
+// Different keys can have the same HashKey, so each HashKey leads to a
+// bucket of pairs, and a key is only found if it equals the key of one
+// of them.
 type Map struct {
-	Pairs map[HashKey]HashPair
+	buckets map[HashKey][]*HashPair
+	pairs   []*HashPair // in insertion order
+
+	// A frozen map can't be changed.
+	Frozen bool
+}
+
+func NewMap() *Map {
+	return &Map{buckets: make(map[HashKey][]*HashPair)}
+}
+
+// find returns the pair stored under key and its index in the bucket.
+func (m *Map) find(key Hashable) (*HashPair, int) {
+	for i, pair := range m.buckets[key.HashKey()] {
+		if keyEqual(pair.Key, key) {
+			return pair, i
+		}
+	}
+	return nil, -1
+}
+
+// Get returns the value stored under key.
+func (m *Map) Get(key Hashable) (Object, bool) {
+	pair, _ := m.find(key)
+	if pair == nil {
+		return nil, false
+	}
+	return pair.Value, true
+}
+
+// Set stores value under key. A new key goes after the existing ones, an
+// existing key keeps its place.
+func (m *Map) Set(key Hashable, value Object) {
+	if pair, _ := m.find(key); pair != nil {
+		pair.Value = value
+		return
+	}
+	pair := &HashPair{Key: key, Value: value}
+	hashKey := key.HashKey()
+	m.buckets[hashKey] = append(m.buckets[hashKey], pair)
+	m.pairs = append(m.pairs, pair)
+}
+
+// Delete removes key from the map and returns the value it had.
+func (m *Map) Delete(key Hashable) (Object, bool) {
+	pair, i := m.find(key)
+	if pair == nil {
+		return nil, false
+	}
+
+	hashKey := key.HashKey()
+	bucket := m.buckets[hashKey]
+	if len(bucket) == 1 {
+		delete(m.buckets, hashKey)
+	} else {
+		m.buckets[hashKey] = append(bucket[:i:i], bucket[i+1:]...)
+	}
+
+	for i, p := range m.pairs {
+		if p == pair {
+			m.pairs = append(m.pairs[:i], m.pairs[i+1:]...)
+			break
+		}
+	}
+	return pair.Value, true
+}
+
+func (m *Map) Len() int { return len(m.pairs) }
+
+// Pairs returns the pairs of the map in insertion order.
+func (m *Map) Pairs() []HashPair {
+	pairs := make([]HashPair, len(m.pairs))
+	for i, pair := range m.pairs {
+		pairs[i] = *pair
+	}
+	return pairs
 }
 
 func (m *Map) Type() ObjectType { return MAP_OBJ }
-func (m *Map) Inspect() string {
+func (m *Map) Inspect() string  { return inspectIn(m, map[Object]bool{}) }
+func (m *Map) inspect(seen map[Object]bool) string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range m.Pairs {
+	for _, pair := range m.Pairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s",
-			pair.Key.Inspect(), pair.Value.Inspect()))
+			pair.Key.Inspect(), inspectIn(pair.Value, seen)))
 	}
 
 	out.WriteString("{")
@@ -230,6 +508,90 @@ func (m *Map) Inspect() string {
 	return out.String()
 }
 
+// Hashable is an object that can be used as a map key.
 type Hashable interface {
+	Object
 	HashKey() HashKey
 }
+
+// AsHashable returns obj as a map key. An array can only be a key if it
+// is frozen, and an array or a tuple only if all its elements can be keys
+// too. An array that holds itself can't be a key, as its HashKey would
+// never end.
+func AsHashable(obj Object) (Hashable, bool) {
+	if !hashable(obj, map[Object]bool{}) {
+		return nil, false
+	}
+	h, ok := obj.(Hashable)
+	return h, ok
+}
+
+// hashable tells whether obj can be a key, inside the arrays and tuples
+// in seen.
+func hashable(obj Object, seen map[Object]bool) bool {
+	var elements []Object
+	switch obj := obj.(type) {
+	case *Array:
+		if !obj.Frozen {
+			return false
+		}
+		elements = obj.Elements
+	case *Tuple:
+		elements = obj.Elements
+	default:
+		_, ok := obj.(Hashable)
+		return ok
+	}
+
+	if seen[obj] {
+		return false
+	}
+	seen[obj] = true
+	defer delete(seen, obj)
+
+	for _, e := range elements {
+		if !hashable(e, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// Freeze makes obj and all the arrays, maps, sets and structs in it
+// immutable.
+func Freeze(obj Object) {
+	switch obj := obj.(type) {
+	case *Array:
+		// An array that is already frozen has been frozen deeply, and
+		// stopping here ends the recursion for an array that holds itself.
+		if obj.Frozen {
+			return
+		}
+		obj.Frozen = true
+		for _, e := range obj.Elements {
+			Freeze(e)
+		}
+	case *Tuple:
+		for _, e := range obj.Elements {
+			Freeze(e)
+		}
+	case *Map:
+		if obj.Frozen {
+			return
+		}
+		obj.Frozen = true
+		for _, pair := range obj.pairs {
+			Freeze(pair.Value)
+		}
+	case *Set:
+		obj.Frozen = true
+	case *Struct:
+		if obj.Frozen {
+			return
+		}
+		obj.Frozen = true
+		for _, value := range obj.Values {
+			Freeze(value)
+		}
+	}
+}
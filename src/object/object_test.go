@@ -1,6 +1,10 @@
 package object
 
-import "testing"
+import (
+	"math"
+	"math/big"
+	"testing"
+)
 
 func TestStringMapKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -17,3 +21,340 @@ func TestStringMapKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestNumberMapKeys(t *testing.T) {
+	five := &Integer{Value: 5}
+	bigFive := &BigInt{Value: big.NewInt(5)}
+	decimalFive, _ := ParseDecimal("5.00")
+	half, _ := ParseDecimal("0.5")
+	otherHalf, _ := ParseDecimal("0.50")
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+
+	if five.HashKey() != bigFive.HashKey() {
+		t.Errorf("5 and 5n have different hash keys")
+	}
+	if five.HashKey() != decimalFive.HashKey() {
+		t.Errorf("5 and 5.00d have different hash keys")
+	}
+	if half.HashKey() != otherHalf.HashKey() {
+		t.Errorf("0.5d and 0.50d have different hash keys")
+	}
+	if (&BigInt{Value: huge}).HashKey() != NewDecimal(huge).HashKey() {
+		t.Errorf("equal BIGINT and DECIMAL have different hash keys")
+	}
+	if half.HashKey() == five.HashKey() {
+		t.Errorf("0.5d and 5 have the same hash key")
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.50", "1.50"},
+		{"-0.05", "-0.05"},
+		{"+3", "3"},
+		{"1_000.25", "1000.25"},
+		{".5", "0.5"},
+		{"5.", "5"},
+		{"2.5e-3", "0.0025"},
+		{"1.5E3", "1500"},
+	}
+
+	for _, tt := range tests {
+		d, ok := ParseDecimal(tt.input)
+		if !ok {
+			t.Errorf("ParseDecimal(%q) failed", tt.input)
+			continue
+		}
+		if d.Inspect() != tt.expected {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.input, d.Inspect(), tt.expected)
+		}
+	}
+
+	for _, input := range []string{"", ".", "abc", "1.2.3", "1e", "1e999999999", "0x10"} {
+		if _, ok := ParseDecimal(input); ok {
+			t.Errorf("ParseDecimal(%q) succeeded", input)
+		}
+	}
+}
+
+func TestDecimalRounding(t *testing.T) {
+	inputs := []string{"2.5", "3.5", "-2.5", "2.51", "-2.49"}
+	tests := []struct {
+		mode     RoundingMode
+		expected []string
+	}{
+		{RoundHalfEven, []string{"2", "4", "-2", "3", "-2"}},
+		{RoundHalfUp, []string{"3", "4", "-3", "3", "-2"}},
+		{RoundHalfDown, []string{"2", "3", "-2", "3", "-2"}},
+		{RoundUp, []string{"3", "4", "-3", "3", "-3"}},
+		{RoundDown, []string{"2", "3", "-2", "2", "-2"}},
+		{RoundCeiling, []string{"3", "4", "-2", "3", "-2"}},
+		{RoundFloor, []string{"2", "3", "-3", "2", "-3"}},
+	}
+
+	for _, tt := range tests {
+		ctx := DecimalContext{Precision: 1, Rounding: tt.mode}
+		for i, input := range inputs {
+			d, _ := ParseDecimal(input)
+			got := ctx.Round(d).Inspect()
+			if got != tt.expected[i] {
+				t.Errorf("rounding %s %s: got %s, want %s", input, tt.mode, got, tt.expected[i])
+			}
+		}
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected string
+	}{
+		{1e-9, "1e-09"},
+		{0.1, "0.1"},
+		{0.0001, "0.0001"},
+		{0.00001, "1e-05"},
+		{2, "2.0"},
+		{-3.25, "-3.25"},
+		{123456789, "123456789.0"},
+		{1e16, "1e+16"},
+		{1.0 / 3, "0.3333333333333333"},
+		{0, "0.0"},
+		{math.Inf(1), "inf"},
+		{math.Inf(-1), "-inf"},
+		{math.NaN(), "nan"},
+	}
+
+	for _, tt := range tests {
+		got := (&Float{Value: tt.input}).Inspect()
+		if got != tt.expected {
+			t.Errorf("Float{%g}.Inspect() = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestEqualAndCompare(t *testing.T) {
+	one := &Integer{Value: 1}
+	two := &Integer{Value: 2}
+	a := &String{Value: "a"}
+	b := &String{Value: "b"}
+	arr := func(elements ...Object) *Array { return &Array{Elements: elements} }
+
+	equal := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{one, &Float{Value: 1}, true},
+		{one, &BigInt{Value: big.NewInt(1)}, true},
+		{one, NewDecimal(big.NewInt(1)), true},
+		{&Float{Value: 1}, NewDecimal(big.NewInt(1)), false},
+		{a, &String{Value: "a"}, true},
+		{a, one, false},
+		{arr(one, a), arr(&Float{Value: 1}, &String{Value: "a"}), true},
+		{arr(one), arr(one, two), false},
+		{&Null{}, &Null{}, true},
+		{&Boolean{Value: true}, &Boolean{Value: true}, true},
+		{&Float{Value: 0.30000000000000004}, &Float{Value: 0.3}, true},
+		{arr(&Float{Value: 0.30000000000000004}), arr(&Float{Value: 0.3}), true},
+	}
+	for _, tt := range equal {
+		if got := Equal(tt.a, tt.b); got != tt.expected {
+			t.Errorf("Equal(%s, %s) = %t, want %t", tt.a.Inspect(), tt.b.Inspect(), got, tt.expected)
+		}
+	}
+
+	compare := []struct {
+		a, b     Object
+		expected int
+		ok       bool
+	}{
+		{one, two, -1, true},
+		{two, &Float{Value: 1.5}, 1, true},
+		{a, b, -1, true},
+		{b, a, 1, true},
+		{arr(one, two), arr(one, two), 0, true},
+		{arr(one), arr(one, two), -1, true},
+		{arr(two), arr(one, two), 1, true},
+		{a, one, 0, false},
+		{arr(a), arr(one), 0, false},
+		{&Boolean{Value: true}, &Boolean{Value: false}, 0, false},
+		{&Float{Value: 100000.5}, &Float{Value: 100000.1}, 1, true},
+		{arr(&Float{Value: 100000.1}), arr(&Float{Value: 100000.5}), -1, true},
+		{&Float{Value: 0.30000000000000004}, &Float{Value: 0.3}, 1, true},
+	}
+	for _, tt := range compare {
+		got, ok := Compare(tt.a, tt.b)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("Compare(%s, %s) = %d, %t, want %d, %t",
+				tt.a.Inspect(), tt.b.Inspect(), got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestMapInsertionOrder(t *testing.T) {
+	m := NewMap()
+	m.Set(&String{Value: "b"}, &Integer{Value: 1})
+	m.Set(&String{Value: "a"}, &Integer{Value: 2})
+	m.Set(&Integer{Value: 3}, &Integer{Value: 3})
+	m.Set(&String{Value: "b"}, &Integer{Value: 4})
+
+	if got := m.Inspect(); got != "{b: 4, a: 2, 3: 3}" {
+		t.Errorf("wrong order after Set. got=%s", got)
+	}
+
+	if value, ok := m.Delete(&String{Value: "a"}); !ok || value.Inspect() != "2" {
+		t.Errorf("Delete returned %v, %t", value, ok)
+	}
+	if _, ok := m.Delete(&String{Value: "a"}); ok {
+		t.Errorf("deleted key is still there")
+	}
+	m.Set(&String{Value: "a"}, &Integer{Value: 5})
+
+	if got := m.Inspect(); got != "{b: 4, 3: 3, a: 5}" {
+		t.Errorf("wrong order after Delete. got=%s", got)
+	}
+	if m.Len() != 3 {
+		t.Errorf("wrong length. got=%d", m.Len())
+	}
+}
+
+// collidingKey is a key whose HashKey is always the same.
+type collidingKey struct{ String }
+
+func (k *collidingKey) HashKey() HashKey { return HashKey{Type: STRING_OBJ} }
+
+func TestMapHashCollisions(t *testing.T) {
+	a := &collidingKey{String{Value: "a"}}
+	b := &collidingKey{String{Value: "b"}}
+
+	m := NewMap()
+	m.Set(a, &Integer{Value: 1})
+	m.Set(b, &Integer{Value: 2})
+
+	if value, ok := m.Get(a); !ok || value.Inspect() != "1" {
+		t.Errorf("Get(a) returned %v, %t", value, ok)
+	}
+	if value, ok := m.Get(b); !ok || value.Inspect() != "2" {
+		t.Errorf("Get(b) returned %v, %t", value, ok)
+	}
+	if _, ok := m.Get(&collidingKey{String{Value: "c"}}); ok {
+		t.Errorf("Get found a key that was never set")
+	}
+
+	m.Delete(a)
+	if _, ok := m.Get(a); ok {
+		t.Errorf("deleted key is still there")
+	}
+	if value, ok := m.Get(b); !ok || value.Inspect() != "2" {
+		t.Errorf("Delete(a) removed b")
+	}
+}
+
+func TestMoreMapKeys(t *testing.T) {
+	m := NewMap()
+	m.Set(&Float{Value: 2.0}, &String{Value: "two"})
+	m.Set(&Float{Value: 0.5}, &String{Value: "half"})
+	m.Set(&Null{}, &String{Value: "null"})
+	m.Set(&Float{Value: math.Inf(1)}, &String{Value: "inf"})
+	m.Set(&Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}, Frozen: true},
+		&String{Value: "pair"})
+
+	tests := []struct {
+		key      Hashable
+		expected string
+	}{
+		{&Integer{Value: 2}, "two"},
+		{&BigInt{Value: big.NewInt(2)}, "two"},
+		{&Float{Value: 0.5}, "half"},
+		{&Float{Value: 0.5000001}, ""},
+		{&Float{Value: math.NaN()}, ""},
+		{&Float{Value: math.Inf(1)}, "inf"},
+		{&Decimal{Unscaled: big.NewInt(200), Scale: 2}, "two"},
+		{&Null{}, "null"},
+		{&Array{Elements: []Object{&Float{Value: 1}, &String{Value: "a"}}, Frozen: true}, "pair"},
+		{&Array{Elements: []Object{&String{Value: "a"}, &Integer{Value: 1}}, Frozen: true}, ""},
+	}
+
+	for _, tt := range tests {
+		value, ok := m.Get(tt.key)
+		if tt.expected == "" {
+			if ok {
+				t.Errorf("Get(%s) found %s", tt.key.Inspect(), value.Inspect())
+			}
+			continue
+		}
+		if !ok || value.Inspect() != tt.expected {
+			t.Errorf("Get(%s) returned %v, %t. want=%s",
+				tt.key.Inspect(), value, ok, tt.expected)
+		}
+	}
+}
+
+func TestAsHashable(t *testing.T) {
+	tests := []struct {
+		obj      Object
+		expected bool
+	}{
+		{&String{Value: "a"}, true},
+		{&Null{}, true},
+		{&Array{Elements: []Object{&Integer{Value: 1}}}, false},
+		{&Array{Elements: []Object{&Integer{Value: 1}}, Frozen: true}, true},
+		{&Array{Elements: []Object{&Array{}}, Frozen: true}, false},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}, &Null{}}}, true},
+		{&Tuple{Elements: []Object{&Array{}}}, false},
+		{&Tuple{Elements: []Object{&Array{Frozen: true}}}, true},
+		{NewMap(), false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := AsHashable(tt.obj); ok != tt.expected {
+			t.Errorf("AsHashable(%s) returned %t, want=%t",
+				tt.obj.Inspect(), ok, tt.expected)
+		}
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	inner := &Array{Elements: []Object{&Integer{Value: 1}}}
+	m := NewMap()
+	m.Set(&String{Value: "a"}, inner)
+	set := NewSet()
+	outer := &Array{Elements: []Object{m, &Tuple{Elements: []Object{set}}}}
+	outer.Elements = append(outer.Elements, outer)
+
+	Freeze(outer)
+
+	if !outer.Frozen || !m.Frozen || !inner.Frozen || !set.Frozen {
+		t.Errorf("Freeze didn't freeze everything. outer=%t map=%t inner=%t set=%t",
+			outer.Frozen, m.Frozen, inner.Frozen, set.Frozen)
+	}
+}
+
+func TestCycles(t *testing.T) {
+	a := &Array{Elements: []Object{&Integer{Value: 1}}}
+	a.Elements = append(a.Elements, a)
+	b := &Array{Elements: []Object{&Integer{Value: 1}}}
+	b.Elements = append(b.Elements, b)
+	m := NewMap()
+	m.Set(&String{Value: "m"}, m)
+
+	if got := a.Inspect(); got != "[1, [...]]" {
+		t.Errorf("wrong Inspect. got=%s", got)
+	}
+	if got := m.Inspect(); got != "{m: {...}}" {
+		t.Errorf("wrong Inspect. got=%s", got)
+	}
+	if !Equal(a, a) || !Equal(a, b) || !Equal(m, m) {
+		t.Errorf("cyclic arrays are not equal")
+	}
+	if c, ok := Compare(a, b); !ok || c != 0 {
+		t.Errorf("wrong Compare. got=%d, %t", c, ok)
+	}
+
+	Freeze(a)
+	if _, ok := AsHashable(a); ok {
+		t.Errorf("an array that holds itself is hashable")
+	}
+}
@@ -0,0 +1,111 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Set is a collection of distinct values, which must be Hashable. Like a
+// Map, it remembers the order its elements were added in.
+type Set struct {
+	elements *Map // every element is stored under itself
+
+	// A frozen set can't be changed.
+	Frozen bool
+}
+
+func NewSet() *Set {
+	return &Set{elements: NewMap()}
+}
+
+// Add adds e to the set, unless the set already has it.
+func (s *Set) Add(e Hashable) {
+	if !s.Has(e) {
+		s.elements.Set(e, e)
+	}
+}
+
+// Has tells whether e is in the set.
+func (s *Set) Has(e Hashable) bool {
+	_, ok := s.elements.Get(e)
+	return ok
+}
+
+// Remove removes e from the set and returns the element that was equal
+// to it.
+func (s *Set) Remove(e Hashable) (Object, bool) {
+	return s.elements.Delete(e)
+}
+
+func (s *Set) Len() int { return s.elements.Len() }
+
+// Elements returns the elements of the set in insertion order.
+func (s *Set) Elements() []Object {
+	pairs := s.elements.Pairs()
+	elements := make([]Object, len(pairs))
+	for i, pair := range pairs {
+		elements[i] = pair.Key
+	}
+	return elements
+}
+
+// Union returns the elements of s followed by those of o that s doesn't
+// have.
+func (s *Set) Union(o *Set) *Set {
+	result := NewSet()
+	for _, e := range s.Elements() {
+		result.Add(e.(Hashable))
+	}
+	for _, e := range o.Elements() {
+		result.Add(e.(Hashable))
+	}
+	return result
+}
+
+// Intersection returns the elements of s that o has too.
+func (s *Set) Intersection(o *Set) *Set {
+	return s.filter(func(e Hashable) bool { return o.Has(e) })
+}
+
+// Difference returns the elements of s that o doesn't have.
+func (s *Set) Difference(o *Set) *Set {
+	return s.filter(func(e Hashable) bool { return !o.Has(e) })
+}
+
+// IsSubset tells whether o has all the elements of s.
+func (s *Set) IsSubset(o *Set) bool {
+	return s.Difference(o).Len() == 0
+}
+
+func (s *Set) filter(keep func(Hashable) bool) *Set {
+	result := NewSet()
+	for _, e := range s.Elements() {
+		if keep(e.(Hashable)) {
+			result.Add(e.(Hashable))
+		}
+	}
+	return result
+}
+
+func (s *Set) Type() ObjectType { return SET_OBJ }
+
+// Inspect lists the elements in braces, like a set literal. An empty set
+// is "set()", as {} is an empty map.
+func (s *Set) Inspect() string {
+	if s.Len() == 0 {
+		return "set()"
+	}
+
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, e := range s.Elements() {
+		elements = append(elements, e.Inspect())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
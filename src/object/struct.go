@@ -0,0 +1,81 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// StructType is a type declared with `struct Point { x, y }`. Calling it
+// with a value for each field makes a Struct.
+type StructType struct {
+	Name   string
+	Fields []string
+
+	// Methods added with impl blocks, by name.
+	Methods map[string]Object
+}
+
+func (st *StructType) Type() ObjectType { return STRUCT_TYPE_OBJ }
+func (st *StructType) Inspect() string {
+	return fmt.Sprintf("struct %s { %s }", st.Name, strings.Join(st.Fields, ", "))
+}
+
+// FieldIndex returns the index of the field with the given name, or -1
+// if the struct has no such field.
+func (st *StructType) FieldIndex(name string) int {
+	for i, field := range st.Fields {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Struct is a value of a StructType. Its type is the name of the struct,
+// so a Point has type "Point".
+type Struct struct {
+	Def    *StructType
+	Values []Object // in the order of Def.Fields
+
+	// A frozen struct can't be changed.
+	Frozen bool
+}
+
+func (s *Struct) Type() ObjectType { return ObjectType(s.Def.Name) }
+func (s *Struct) Inspect() string  { return inspectIn(s, map[Object]bool{}) }
+func (s *Struct) inspect(seen map[Object]bool) string {
+	var out bytes.Buffer
+
+	fields := []string{}
+	for i, field := range s.Def.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s", field, inspectIn(s.Values[i], seen)))
+	}
+
+	out.WriteString(s.Def.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// Get returns the value of a field.
+func (s *Struct) Get(field string) (Object, bool) {
+	i := s.Def.FieldIndex(field)
+	if i < 0 {
+		return nil, false
+	}
+	return s.Values[i], true
+}
+
+// Set changes the value of a field. It returns false if the struct has
+// no such field.
+func (s *Struct) Set(field string, value Object) bool {
+	i := s.Def.FieldIndex(field)
+	if i < 0 {
+		return false
+	}
+	s.Values[i] = value
+	return true
+}
@@ -11,3 +11,9 @@ type Error struct {
 func (e *Error) String() string {
 	return fmt.Sprintf("Parser Error on line %d, col %d: %s", e.line, e.col, e.msg)
 }
+
+// Located formats the error the way compilers do, prefixed with the file
+// name and position, e.g. "script.rz:12:5: msg".
+func (e *Error) Located(filename string) string {
+	return fmt.Sprintf("%s:%d:%d: %s", filename, e.line, e.col, e.msg)
+}
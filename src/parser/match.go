@@ -0,0 +1,365 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/batt0s/rizzy/ast"
+	"github.com/batt0s/rizzy/token"
+)
+
+// parseEnumStatement parses an enum declaration,
+// enum Shape { Circle(r), Rect(w, h), Empty }.
+func (p *Parser) parseEnumStatement() ast.Statement {
+	stmt := &ast.EnumStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		variant := &ast.EnumVariant{
+			Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		}
+		if seen[variant.Name.Value] {
+			msg := fmt.Sprintf("duplicate variant %s in enum %s",
+				variant.Name.Value, stmt.Name.Value)
+			p.errorAt(variant.Name.Pos(), msg)
+			return nil
+		}
+		seen[variant.Name.Value] = true
+
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			variant.Fields = p.parseFunctionParameters()
+			if variant.Fields == nil {
+				return nil
+			}
+			if len(variant.Fields) == 0 {
+				msg := fmt.Sprintf("variant %s has no fields, leave out the parentheses",
+					variant.Name.Value)
+				p.errorAt(variant.Name.Pos(), msg)
+				return nil
+			}
+		}
+		stmt.Variants = append(stmt.Variants, variant)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	stmt.Rbrace = p.curToken
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseMatchExpression parses match (subject) { pattern => body, ... }.
+// A body is an expression or a block.
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		expression.Arms = append(expression.Arms, arm)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	expression.Rbrace = p.curToken
+
+	return expression
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Pattern: p.parsePattern()}
+	if arm.Pattern == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		arm.Guard = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+
+	// A body in braces is a block, any other body an expression.
+	if p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		arm.Body = p.parseBlockStatement()
+		return arm
+	}
+
+	p.nextToken()
+	stmt := &ast.ExpressionStatement{Token: p.curToken, Expression: p.parseExpression(LOWEST)}
+	if stmt.Expression == nil {
+		return nil
+	}
+	arm.Body = &ast.BlockStatement{Token: stmt.Token, Statements: []ast.Statement{stmt}}
+
+	return arm
+}
+
+// parsePattern parses the pattern that starts at the current token. A
+// bare name always binds, even the name of a struct, whose values are
+// matched with Point(_, _).
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if p.peekTokenIs(token.DOT) || p.peekTokenIs(token.LPAREN) {
+			return p.parseConstructorPattern(name)
+		}
+		return &ast.BindingPattern{Name: name}
+	case token.INT, token.BIGINT, token.FLOAT, token.DECIMAL, token.STRING,
+		token.TRUE, token.FALSE, token.NULL, token.MINUS:
+		return p.parseLiteralPattern()
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LPAREN:
+		return p.parseTuplePattern()
+	case token.LBRACE:
+		return p.parseMapPattern()
+	case token.ILLEGAL:
+		p.errorAt(p.curToken.Pos, p.curToken.Literal)
+		return nil
+	default:
+		msg := fmt.Sprintf("unexpected %s in pattern", p.curToken.Type)
+		p.errorAt(p.curToken.Pos, msg)
+		return nil
+	}
+}
+
+// parseLiteral parses a literal in a pattern: a number, which may be
+// negative, a string, true, false or null.
+func (p *Parser) parseLiteral() ast.Expression {
+	if p.curTokenIs(token.MINUS) {
+		if !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.BIGINT) &&
+			!p.peekTokenIs(token.FLOAT) && !p.peekTokenIs(token.DECIMAL) {
+			msg := fmt.Sprintf("expected a number after - in pattern, got %s", p.peekToken.Type)
+			p.errorAt(p.peekToken.Pos, msg)
+			return nil
+		}
+		expression := &ast.PrefixExpression{Token: p.curToken, Operator: "-"}
+		p.nextToken()
+		expression.Right = p.prefixParseFns[p.curToken.Type]()
+		if expression.Right == nil {
+			return nil
+		}
+		return expression
+	}
+
+	switch p.curToken.Type {
+	case token.INT, token.BIGINT, token.FLOAT, token.DECIMAL, token.STRING,
+		token.TRUE, token.FALSE, token.NULL:
+		return p.prefixParseFns[p.curToken.Type]()
+	}
+
+	msg := fmt.Sprintf("expected a literal, got %s", p.curToken.Type)
+	p.errorAt(p.curToken.Pos, msg)
+	return nil
+}
+
+func (p *Parser) parseLiteralPattern() ast.Pattern {
+	value := p.parseLiteral()
+	if value == nil {
+		return nil
+	}
+
+	if !p.peekTokenIs(token.DOTDOT) {
+		return &ast.LiteralPattern{Value: value}
+	}
+
+	p.nextToken()
+	p.nextToken()
+	high := p.parseLiteral()
+	if high == nil {
+		return nil
+	}
+	return &ast.RangePattern{Low: value, High: high}
+}
+
+func (p *Parser) parseConstructorPattern(name *ast.Identifier) ast.Pattern {
+	pattern := &ast.ConstructorPattern{Type: name}
+
+	for p.peekTokenIs(token.DOT) {
+		p.nextToken()
+		dot := &ast.DotExpression{Token: p.curToken, Left: pattern.Type}
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		dot.Field = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		pattern.Type = dot
+	}
+
+	if !p.peekTokenIs(token.LPAREN) {
+		return pattern
+	}
+	p.nextToken()
+	pattern.HasArgs = true
+
+	for !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		arg := p.parsePattern()
+		if arg == nil {
+			return nil
+		}
+		pattern.Args = append(pattern.Args, arg)
+
+		if !p.peekTokenIs(token.RPAREN) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	pattern.Rparen = p.curToken
+
+	return pattern
+}
+
+// parseArrayPattern parses [a, b] or [a, ..rest], where the rest comes
+// last.
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+
+		if p.curTokenIs(token.DOTDOT) {
+			pattern.HasRest = true
+			if p.peekTokenIs(token.IDENT) {
+				p.nextToken()
+				pattern.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			}
+			if p.peekTokenIs(token.COMMA) {
+				p.nextToken()
+			}
+			if !p.expectPeek(token.RBRACKET) {
+				return nil
+			}
+			pattern.Rbracket = p.curToken
+			return pattern
+		}
+
+		element := p.parsePattern()
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.peekTokenIs(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	pattern.Rbracket = p.curToken
+
+	return pattern
+}
+
+// parseTuplePattern parses (a, b) or (a,). A single pattern in parentheses
+// without a comma is just that pattern.
+func (p *Parser) parseTuplePattern() ast.Pattern {
+	pattern := &ast.TuplePattern{Token: p.curToken}
+	comma := false
+
+	for !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		element := p.parsePattern()
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+
+		if !p.peekTokenIs(token.RPAREN) {
+			if !p.expectPeek(token.COMMA) {
+				return nil
+			}
+			comma = true
+		}
+	}
+
+	p.nextToken()
+	pattern.Rparen = p.curToken
+
+	if len(pattern.Elements) == 1 && !comma {
+		return pattern.Elements[0]
+	}
+	return pattern
+}
+
+// parseMapPattern parses {"key": pattern, ...}. The keys are literals.
+func (p *Parser) parseMapPattern() ast.Pattern {
+	pattern := &ast.MapPattern{Token: p.curToken}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseLiteral()
+		if key == nil {
+			return nil
+		}
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+		p.nextToken()
+		value := p.parsePattern()
+		if value == nil {
+			return nil
+		}
+
+		pattern.Keys = append(pattern.Keys, key)
+		pattern.Values = append(pattern.Values, value)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	pattern.Rbrace = p.curToken
+
+	return pattern
+}
@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/batt0s/rizzy/ast"
 	"github.com/batt0s/rizzy/lexer"
@@ -12,34 +15,58 @@ import (
 const (
 	_ int = iota
 	LOWEST
-	LOGICAL     // &&, ||
+	ASSIGN      // =, +=, -=, *=, /=
+	CONDITIONAL // a ? b : c
+	NULLISH     // ??
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
 	EQUALS      // ==, !=
 	LESSGREATER // >, <, <=, >=
-	BITWISE     // &, |
+	BITWISE     // &, |, ^
+	SHIFT       // <<, >>
 	SUM         // +, -
-	PRODUCT     // *, /
+	PRODUCT     // *, /, %
 	PREFIX      // -X or !X
+	POWER       // ** binds tighter than prefix operators, -2 ** 2 is -4
 	CALL        // myFunction(X)
 	INDEX       // array[index]
 )
 
 var precedences = map[token.TokenType]int{
-	token.AND:      LOGICAL,
-	token.OR:       LOGICAL,
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.LTE:      LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.GTE:      LESSGREATER,
-	token.BIT_AND:  BITWISE,
-	token.BIT_OR:   BITWISE,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.ASTERISK_ASSIGN: ASSIGN,
+	token.SLASH_ASSIGN:    ASSIGN,
+	token.QUESTION:        CONDITIONAL,
+	token.NULLISH:         NULLISH,
+	token.OR:              LOGICAL_OR,
+	token.AND:             LOGICAL_AND,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.IS:              EQUALS,
+	token.IN:              EQUALS,
+	token.NOT:             EQUALS,
+	token.LT:              LESSGREATER,
+	token.LTE:             LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.GTE:             LESSGREATER,
+	token.BIT_AND:         BITWISE,
+	token.BIT_OR:          BITWISE,
+	token.BIT_XOR:         BITWISE,
+	token.SHL:             SHIFT,
+	token.SHR:             SHIFT,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.PERCENT:         PRODUCT,
+	token.POWER:           POWER,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+	token.OPT_LBRACKET:    INDEX,
+	token.OPT_DOT:         INDEX,
+	token.DOT:             INDEX,
 }
 
 type (
@@ -54,6 +81,10 @@ type Parser struct {
 	curToken  token.Token
 	peekToken token.Token
 
+	// loopDepth counts the loops enclosing the current token within the
+	// current function, to reject break and continue outside of loops.
+	loopDepth int
+
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
@@ -66,17 +97,25 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.BIGINT, p.parseBigIntLiteral)
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.DECIMAL, p.parseDecimalLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNullLiteral)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpressions)
 	p.registerPrefix(token.IF, p.parseIfExpression)
+	p.registerPrefix(token.WHILE, p.parseWhileExpression)
+	p.registerPrefix(token.FOR, p.parseForExpression)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.INTERP_START, p.parseInterpolatedString)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseMapLiteral)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -85,16 +124,34 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.IS, p.parseIsExpression)
+	p.registerInfix(token.IN, p.parseInfixExpression)
+	p.registerInfix(token.NOT, p.parseNotInExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.LTE, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.GTE, p.parseInfixExpression)
 	p.registerInfix(token.AND, p.parseInfixExpression)
 	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.QUESTION, p.parseConditionalExpression)
 	p.registerInfix(token.BIT_AND, p.parseInfixExpression)
 	p.registerInfix(token.BIT_OR, p.parseInfixExpression)
+	p.registerInfix(token.BIT_XOR, p.parseInfixExpression)
+	p.registerInfix(token.SHL, p.parseInfixExpression)
+	p.registerInfix(token.SHR, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.OPT_LBRACKET, p.parseOptionalIndexExpression)
+	p.registerInfix(token.OPT_DOT, p.parseDotExpression)
+	p.registerInfix(token.DOT, p.parseDotExpression)
 
 	p.nextToken()
 	p.nextToken()
@@ -115,6 +172,16 @@ func (p *Parser) Errors() []string {
 	return msgs
 }
 
+// FileErrors returns the errors prefixed with the name of the file that
+// was parsed.
+func (p *Parser) FileErrors(filename string) []string {
+	msgs := []string{}
+	for _, err := range p.errors {
+		msgs = append(msgs, err.Located(filename))
+	}
+	return msgs
+}
+
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
@@ -136,6 +203,16 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseDefStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
+	case token.STRUCT:
+		return p.parseStructStatement()
+	case token.IMPL:
+		return p.parseImplStatement()
+	case token.ENUM:
+		return p.parseEnumStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -170,6 +247,85 @@ func (p *Parser) parseDefStatement() ast.Statement {
 	return stmt
 }
 
+// parseStructStatement parses a struct declaration, struct Point { x, y }.
+func (p *Parser) parseStructStatement() ast.Statement {
+	stmt := &ast.StructStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		field := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if seen[field.Value] {
+			msg := fmt.Sprintf("duplicate field %s in struct %s", field.Value, stmt.Name.Value)
+			p.errorAt(field.Pos(), msg)
+			return nil
+		}
+		seen[field.Value] = true
+		stmt.Fields = append(stmt.Fields, field)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	p.nextToken()
+	stmt.Rbrace = p.curToken
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseImplStatement parses an impl block, which holds the def statements
+// of the methods of a struct.
+func (p *Parser) parseImplStatement() ast.Statement {
+	stmt := &ast.ImplStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+
+	for !p.curTokenIs(token.RBRACE) {
+		if !p.curTokenIs(token.DEF) {
+			msg := fmt.Sprintf("expected a def statement in impl block, got %s", p.curToken.Type)
+			p.errorAt(p.curToken.Pos, msg)
+			return nil
+		}
+		def, ok := p.parseDefStatement().(*ast.DefStatement)
+		if !ok {
+			return nil
+		}
+		stmt.Methods = append(stmt.Methods, def)
+		p.nextToken()
+	}
+	stmt.Rbrace = p.curToken
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) curTokenIs(t token.TokenType) bool {
 	return p.curToken.Type == t
 }
@@ -189,10 +345,17 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 }
 
 func (p *Parser) peekError(t token.TokenType) {
+	if p.peekTokenIs(token.ILLEGAL) {
+		p.errorAt(p.peekToken.Pos, p.peekToken.Literal)
+		return
+	}
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
 		t, p.peekToken.Type)
-	l, c := p.l.Trace()
-	p.errors = append(p.errors, Error{msg: msg, line: l, col: c})
+	p.errorAt(p.peekToken.Pos, msg)
+}
+
+func (p *Parser) errorAt(pos token.Position, msg string) {
+	p.errors = append(p.errors, Error{msg: msg, line: pos.Line, col: pos.Column})
 }
 
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
@@ -211,6 +374,34 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+func (p *Parser) parseBreakStatement() *ast.BreakStatement {
+	stmt := &ast.BreakStatement{Token: p.curToken}
+
+	if p.loopDepth == 0 {
+		p.errorAt(p.curToken.Pos, "break outside of a loop")
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+
+	if p.loopDepth == 0 {
+		p.errorAt(p.curToken.Pos, "continue outside of a loop")
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
 	p.prefixParseFns[tokenType] = fn
 }
@@ -266,10 +457,30 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		// Too large for INTEGER, so it becomes a BIGINT.
+		return p.parseBigIntLiteral()
+	}
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		l, c := p.l.Trace()
-		p.errors = append(p.errors, Error{msg: msg, line: l, col: c})
+		msg := fmt.Sprintf("malformed integer literal %q", p.curToken.Literal)
+		p.errorAt(p.curToken.Pos, msg)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
+func (p *Parser) parseBigIntLiteral() ast.Expression {
+	lit := &ast.BigIntLiteral{
+		Token: p.curToken,
+	}
+
+	value, ok := new(big.Int).SetString(strings.TrimSuffix(p.curToken.Literal, "n"), 0)
+	if !ok {
+		msg := fmt.Sprintf("malformed integer literal %q", p.curToken.Literal)
+		p.errorAt(p.curToken.Pos, msg)
 		return nil
 	}
 
@@ -285,9 +496,11 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
-		l, c := p.l.Trace()
-		p.errors = append(p.errors, Error{msg: msg, line: l, col: c})
+		msg := fmt.Sprintf("malformed float literal %q", p.curToken.Literal)
+		if errors.Is(err, strconv.ErrRange) {
+			msg = fmt.Sprintf("float literal %q is out of range", p.curToken.Literal)
+		}
+		p.errorAt(p.curToken.Pos, msg)
 		return nil
 	}
 
@@ -296,10 +509,34 @@ func (p *Parser) parseFloatLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseDecimalLiteral() ast.Expression {
+	lit := &ast.DecimalLiteral{
+		Token: p.curToken,
+		Value: strings.TrimSuffix(p.curToken.Literal, "d"),
+	}
+
+	// A decimal literal is written like a float literal. Its range
+	// isn't limited, so only the syntax is checked here.
+	_, err := strconv.ParseFloat(lit.Value, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		msg := fmt.Sprintf("malformed decimal literal %q", p.curToken.Literal)
+		p.errorAt(p.curToken.Pos, msg)
+		return nil
+	}
+
+	return lit
+}
+
 func (p *Parser) noPrefixParserFnError(t token.TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	l, c := p.l.Trace()
-	p.errors = append(p.errors, Error{msg: msg, line: l, col: c})
+	p.errorAt(p.curToken.Pos, msg)
+}
+
+// parseIllegal reports the error the lexer found. The literal of an
+// ILLEGAL token is the lexer's error message.
+func (p *Parser) parseIllegal() ast.Expression {
+	p.errorAt(p.curToken.Pos, p.curToken.Literal)
+	return nil
 }
 
 func (p *Parser) parsePrefixExpression() ast.Expression {
@@ -334,12 +571,93 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedence := p.curPrecedence()
+	if expression.Operator == "**" {
+		// Right associative, 2 ** 3 ** 2 is 2 ** (3 ** 2).
+		precedence--
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
 	return expression
 }
 
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Target:   target,
+	}
+
+	switch target := target.(type) {
+	case *ast.Identifier:
+	case *ast.IndexExpression:
+		if target.Optional {
+			msg := fmt.Sprintf("cannot assign to %s", target.String())
+			p.errorAt(target.Pos(), msg)
+			return nil
+		}
+	case *ast.DotExpression:
+		if target.Optional {
+			msg := fmt.Sprintf("cannot assign to %s", target.String())
+			p.errorAt(target.Pos(), msg)
+			return nil
+		}
+	case nil:
+		return nil
+	default:
+		msg := fmt.Sprintf("cannot assign to %s", target.String())
+		p.errorAt(target.Pos(), msg)
+		return nil
+	}
+
+	// Assignment is right associative, "a = b = 1" assigns 1 to both.
+	p.nextToken()
+	expression.Value = p.parseExpression(ASSIGN - 1)
+
+	return expression
+}
+
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
+// parseIsExpression parses "a is b" and "a is not b".
+func (p *Parser) parseIsExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	if p.peekTokenIs(token.NOT) {
+		p.nextToken()
+		expression.Operator = "is not"
+	}
+
+	p.nextToken()
+	expression.Right = p.parseExpression(EQUALS)
+
+	return expression
+}
+
+// parseNotInExpression parses `a not in b`, the negation of `a in b`.
+func (p *Parser) parseNotInExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: "not in",
+		Left:     left,
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Right = p.parseExpression(EQUALS)
+
+	return expression
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{
 		Token: p.curToken,
@@ -347,11 +665,24 @@ func (p *Parser) parseBoolean() ast.Expression {
 	}
 }
 
+// parseGroupedExpressions parses an expression in parentheses, or a
+// tuple if there is a comma after the first expression or nothing in the
+// parentheses.
 func (p *Parser) parseGroupedExpressions() ast.Expression {
+	lparen := p.curToken
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return &ast.TupleLiteral{Token: lparen, Rparen: p.curToken}
+	}
+
 	p.nextToken()
 
 	exp := p.parseExpression(LOWEST)
 
+	if p.peekTokenIs(token.COMMA) {
+		return p.parseTupleLiteral(lparen, exp)
+	}
+
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
@@ -359,6 +690,28 @@ func (p *Parser) parseGroupedExpressions() ast.Expression {
 	return exp
 }
 
+// parseTupleLiteral parses the rest of a tuple literal after its first
+// element.
+func (p *Parser) parseTupleLiteral(lparen token.Token, first ast.Expression) ast.Expression {
+	tuple := &ast.TupleLiteral{Token: lparen, Elements: []ast.Expression{first}}
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if p.peekTokenIs(token.RPAREN) {
+			break
+		}
+		p.nextToken()
+		tuple.Elements = append(tuple.Elements, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	tuple.Rparen = p.curToken
+
+	return tuple
+}
+
 func (p *Parser) parseIfExpression() ast.Expression {
 	expression := &ast.IfExpression{Token: p.curToken}
 
@@ -381,6 +734,21 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	if p.peekTokenIs(token.ELSE) {
 		p.nextToken()
 
+		// else if (...) { ... } becomes a block holding the next if.
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			stmt := &ast.ExpressionStatement{Token: p.curToken}
+			stmt.Expression = p.parseIfExpression()
+			if stmt.Expression == nil {
+				return nil
+			}
+			expression.Alternative = &ast.BlockStatement{
+				Token:      stmt.Token,
+				Statements: []ast.Statement{stmt},
+			}
+			return expression
+		}
+
 		if !p.expectPeek(token.LBRACE) {
 			return nil
 		}
@@ -391,6 +759,97 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+// parseConditionalExpression parses cond ? a : b. Both branches take any
+// expression, so it is right associative, a ? b : c ? d : e is
+// a ? b : (c ? d : e).
+func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	expression := &ast.ConditionalExpression{Token: p.curToken, Condition: condition}
+
+	p.nextToken()
+	expression.Consequence = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Alternative = p.parseExpression(LOWEST)
+
+	return expression
+}
+
+func (p *Parser) parseWhileExpression() ast.Expression {
+	expression := &ast.WhileExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	p.loopDepth++
+	expression.Body = p.parseBlockStatement()
+	p.loopDepth--
+
+	return expression
+}
+
+func (p *Parser) parseForExpression() ast.Expression {
+	expression := &ast.ForExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	expression.Value = &ast.Identifier{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		expression.Key = expression.Value
+		expression.Value = &ast.Identifier{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		}
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	p.loopDepth++
+	expression.Body = p.parseBlockStatement()
+	p.loopDepth--
+
+	return expression
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
@@ -404,6 +863,7 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		}
 		p.nextToken()
 	}
+	block.Rbrace = p.curToken
 
 	return block
 }
@@ -421,7 +881,11 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
+	// A loop around the function literal does not extend into its body.
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
 	lit.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
 
 	return lit
 }
@@ -465,6 +929,7 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 		Function: function,
 	}
 	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Rparen = p.curToken
 	return exp
 }
 
@@ -475,10 +940,35 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	}
 }
 
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	str.Parts = []ast.Expression{p.parseStringLiteral()}
+
+	for {
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+
+		switch {
+		case p.peekTokenIs(token.INTERP_MID):
+			p.nextToken()
+			str.Parts = append(str.Parts, p.parseStringLiteral())
+		case p.peekTokenIs(token.INTERP_END):
+			p.nextToken()
+			str.Parts = append(str.Parts, p.parseStringLiteral())
+			str.Close = p.curToken
+			return str
+		default:
+			p.peekError(token.INTERP_END)
+			return nil
+		}
+	}
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	array.Rbracket = p.curToken
 
 	return array
 }
@@ -519,6 +1009,69 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
+	exp.Rbracket = p.curToken
+
+	return exp
+}
+
+// parseOptionalIndexExpression parses `left?[index]`. When a ':' follows
+// the ']' the '?' was a conditional instead, as in `c ?[1] : [2]`, and
+// the brackets are the array literal of its consequence.
+func (p *Parser) parseOptionalIndexExpression(left ast.Expression) ast.Expression {
+	optLbracket := p.curToken
+	question := token.Token{Type: token.QUESTION, Literal: "?", Pos: optLbracket.Pos}
+	question.End = token.Position{
+		Line:   optLbracket.Pos.Line,
+		Column: optLbracket.Pos.Column + 1,
+		Offset: optLbracket.Pos.Offset + 1,
+	}
+	lbracket := token.Token{Type: token.LBRACKET, Literal: "[", Pos: question.End, End: optLbracket.End}
+
+	array := &ast.ArrayLiteral{Token: lbracket}
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if array.Elements == nil {
+		return nil
+	}
+	array.Rbracket = p.curToken
+
+	if p.peekTokenIs(token.COLON) {
+		expression := &ast.ConditionalExpression{
+			Token:       question,
+			Condition:   left,
+			Consequence: array,
+		}
+		p.nextToken()
+		p.nextToken()
+		expression.Alternative = p.parseExpression(LOWEST)
+		return expression
+	}
+
+	if len(array.Elements) != 1 {
+		msg := fmt.Sprintf("expected one index, got %d", len(array.Elements))
+		p.errorAt(lbracket.Pos, msg)
+		return nil
+	}
+
+	return &ast.IndexExpression{
+		Token:    optLbracket,
+		Left:     left,
+		Index:    array.Elements[0],
+		Rbracket: array.Rbracket,
+		Optional: true,
+	}
+}
+
+func (p *Parser) parseDotExpression(left ast.Expression) ast.Expression {
+	exp := &ast.DotExpression{
+		Token:    p.curToken,
+		Left:     left,
+		Optional: p.curTokenIs(token.OPT_DOT),
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Field = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	return exp
 }
@@ -531,6 +1084,11 @@ func (p *Parser) parseMapLiteral() ast.Expression {
 		p.nextToken()
 		key := p.parseExpression(LOWEST)
 
+		// Without a colon after the first element it is a set.
+		if len(hash.Keys) == 0 && !p.peekTokenIs(token.COLON) {
+			return p.parseSetLiteral(hash.Token, key)
+		}
+
 		if !p.expectPeek(token.COLON) {
 			return nil
 		}
@@ -539,6 +1097,7 @@ func (p *Parser) parseMapLiteral() ast.Expression {
 		value := p.parseExpression(LOWEST)
 
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -548,6 +1107,31 @@ func (p *Parser) parseMapLiteral() ast.Expression {
 	if !p.expectPeek(token.RBRACE) {
 		return nil
 	}
+	hash.Rbrace = p.curToken
 
 	return hash
 }
+
+// parseSetLiteral parses the rest of a set literal after its first
+// element.
+func (p *Parser) parseSetLiteral(lbrace token.Token, first ast.Expression) ast.Expression {
+	set := &ast.SetLiteral{Token: lbrace, Elements: []ast.Expression{first}}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectPeek(token.COMMA) {
+			return nil
+		}
+		if p.peekTokenIs(token.RBRACE) {
+			break
+		}
+		p.nextToken()
+		set.Elements = append(set.Elements, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	set.Rbrace = p.curToken
+
+	return set
+}
@@ -413,6 +413,130 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a % b * c",
+			"((a % b) * c)",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a ?? b || c",
+			"(a ?? (b || c))",
+		},
+		{
+			"a == b && c != d",
+			"((a == b) && (c != d))",
+		},
+		{
+			"a?.b?.c",
+			"((a?.b)?.c)",
+		},
+		{
+			"a.b.c + d.e[1]",
+			"(((a.b).c) + ((d.e)[1]))",
+		},
+		{
+			"p.x = -q.y",
+			"(p.x) = (-(q.y))",
+		},
+		{
+			"a || b ? c + 1 : d",
+			"((a || b) ? (c + 1) : d)",
+		},
+		{
+			"a ? b : c ? d : e",
+			"(a ? b : (c ? d : e))",
+		},
+		{
+			"a ?? b ? c : d",
+			"((a ?? b) ? c : d)",
+		},
+		{
+			"c ? [x] : y",
+			"(c ? [x] : y)",
+		},
+		{
+			"c ?.5 : 1",
+			"(c ? .5 : 1)",
+		},
+		{
+			"c ?[1] : [2]",
+			"(c ? [1] : [2])",
+		},
+		{
+			"c ? [1] : [2]",
+			"(c ? [1] : [2])",
+		},
+		{
+			"c ?[1, 2] : []",
+			"(c ? [1, 2] : [])",
+		},
+		{
+			"x = a ? b = 1 : c = 2",
+			"x = (a ? b = 1 : c = 2)",
+		},
+		{
+			"x is not null && y is null",
+			"((x is not null) && (y is null))",
+		},
+		{
+			"a + b is null",
+			"((a + b) is null)",
+		},
+		{
+			"a + 1 in s && b not in s",
+			"(((a + 1) in s) && (b not in s))",
+		},
+		{
+			"{a, b + 1} | s",
+			"({a, (b + 1)} | s)",
+		},
+		{
+			"m[\"k\"] == null",
+			"((m[\"k\"]) == null)",
+		},
+		{
+			"a?[1]?.b[2]",
+			"(((a?[1])?.b)[2])",
+		},
+		{
+			"-a?.b + c",
+			"((-(a?.b)) + c)",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"-2 ** 2",
+			"(-(2 ** 2))",
+		},
+		{
+			"a * b ** c",
+			"(a * (b ** c))",
+		},
+		{
+			"2 ** -1",
+			"(2 ** (-1))",
+		},
+		{
+			"a << b + c",
+			"(a << (b + c))",
+		},
+		{
+			"a & b >> c",
+			"(a & (b >> c))",
+		},
+		{
+			"a ^ b | c",
+			"((a ^ b) | c)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -648,6 +772,68 @@ func TestIfElseExpression(t *testing.T) {
 	}
 }
 
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < 0) { a } else if (x == 0) { b } else { c }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Body does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	}
+	if !testInfixExpression(t, exp.Condition, "x", "<", 0) {
+		return
+	}
+
+	if len(exp.Alternative.Statements) != 1 {
+		t.Fatalf("exp.Alternative.Statements does not contain 1 statements. got=%d\n",
+			len(exp.Alternative.Statements))
+	}
+	alternative, ok := exp.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Statements[0] is not ast.ExpressionStatement. got=%T",
+			exp.Alternative.Statements[0])
+	}
+	elseIf, ok := alternative.Expression.(*ast.IfExpression)
+	if !ok {
+		t.Fatalf("alternative is not ast.IfExpression. got=%T", alternative.Expression)
+	}
+	if !testInfixExpression(t, elseIf.Condition, "x", "==", 0) {
+		return
+	}
+	if elseIf.Alternative == nil {
+		t.Fatalf("else if has no else branch")
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"if (a) { 1 } else if { 2 }", "Parser Error on line 1, col 22: expected next token to be (, got { instead"},
+		{"if (a) { 1 } else 2", "Parser Error on line 1, col 19: expected next token to be {, got INT instead"},
+		{"a ? b", "Parser Error on line 1, col 6: expected next token to be :, got EOF instead"},
+		{"a?[1, 2]", "Parser Error on line 1, col 3: expected one index, got 2"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors. expected=%q, got=%q", tt.expected, errors)
+		}
+	}
+}
+
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `func(x, y) { x + y; }`
 
@@ -898,7 +1084,7 @@ func TestParsingMapLiteralsStringKeys(t *testing.T) {
 		if !ok {
 			t.Errorf("key is not ast.StringLiteral. got=%T", key)
 		}
-		expectedValue := expected[literal.String()]
+		expectedValue := expected[literal.Value]
 		testIntegerLiteral(t, value, expectedValue)
 	}
 }
@@ -1028,12 +1214,644 @@ func TestParsingMapLiteralsWithExpressions(t *testing.T) {
 			continue
 		}
 
-		testFunc, ok := tests[literal.String()]
+		testFunc, ok := tests[literal.Value]
 		if !ok {
-			t.Errorf("No test function for key %q found", literal.String())
+			t.Errorf("No test function for key %q found", literal.Value)
 			continue
 		}
 
 		testFunc(value)
 	}
 }
+
+func TestNodePositions(t *testing.T) {
+	input := `def add = func(x, y) {
+  x + y
+};
+add(1, [2][0]);`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d",
+			len(program.Statements))
+	}
+
+	def := program.Statements[0].(*ast.DefStatement)
+	fn := def.Value.(*ast.FunctionLiteral)
+	infix := fn.Body.Statements[0].(*ast.ExpressionStatement).Expression
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	index := call.Arguments[1]
+
+	tests := []struct {
+		node     ast.Node
+		pos, end string
+	}{
+		{def, "1:1", "3:2"},
+		{fn, "1:11", "3:2"},
+		{fn.Body, "1:22", "3:2"},
+		{infix, "2:3", "2:8"},
+		{call, "4:1", "4:15"},
+		{index, "4:8", "4:14"},
+		{program, "1:1", "4:15"},
+	}
+
+	for i, tt := range tests {
+		if got := tt.node.Pos().String(); got != tt.pos {
+			t.Errorf("tests[%d] - %T Pos() wrong. expected=%s, got=%s",
+				i, tt.node, tt.pos, got)
+		}
+		if got := tt.node.End().String(); got != tt.end {
+			t.Errorf("tests[%d] - %T End() wrong. expected=%s, got=%s",
+				i, tt.node, tt.end, got)
+		}
+	}
+}
+
+func TestParserErrorPosition(t *testing.T) {
+	input := `def x = 5;
+def = 10;`
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+
+	expected := "Parser Error on line 2, col 5: expected next token to be IDENT, got = instead"
+	if errors[0] != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, errors[0])
+	}
+}
+
+func TestParserFileErrors(t *testing.T) {
+	input := "def x = 5;\n\n  return ;"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.FileErrors("script.rz")
+	if len(errors) == 0 {
+		t.Fatalf("expected parser errors, got none")
+	}
+
+	expected := "script.rz:3:10: no prefix parse function for ; found"
+	if errors[0] != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, errors[0])
+	}
+}
+
+func TestIllegalTokenErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`def s = "abc;`, "Parser Error on line 1, col 9: unterminated string literal"},
+		{`def s = "a\qc";`, `Parser Error on line 1, col 9: unknown escape sequence \q`},
+		{"def s = 1 @ 2;", "Parser Error on line 1, col 11: unexpected character '@'"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestInterpolatedStringParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello ${name}!"`, `"hello ${name}!"`},
+		{`"${a + b * 2}"`, `"${(a + (b * 2))}"`},
+		{`"n: ${len(items)}, q: \"${"in ${x}"}\""`, `"n: ${len(items)}, q: \"${"in ${x}"}\""`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		str, ok := stmt.Expression.(*ast.InterpolatedString)
+		if !ok {
+			t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+		}
+
+		if str.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, str.String())
+		}
+	}
+}
+
+func TestNumberLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"0xFF", int64(255)},
+		{"0b1010", int64(10)},
+		{"0o755", int64(493)},
+		{"1_000_000", int64(1000000)},
+		{"1e9", 1e9},
+		{"1.5e-3", 1.5e-3},
+		{"2_500.5", 2500.5},
+		{"123n", "123"},
+		{"0xFFn", "255"},
+		{"9223372036854775808", "9223372036854775808"},
+		{"1_000.50d", ast.DecimalLiteral{Value: "1_000.50"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+		switch expected := tt.expected.(type) {
+		case int64:
+			literal, ok := exp.(*ast.IntegerLiteral)
+			if !ok {
+				t.Fatalf("exp not *ast.IntegerLiteral. got=%T", exp)
+			}
+			if literal.Value != expected {
+				t.Errorf("literal.Value not %d. got=%d", expected, literal.Value)
+			}
+		case float64:
+			literal, ok := exp.(*ast.FloatLiteral)
+			if !ok {
+				t.Fatalf("exp not *ast.FloatLiteral. got=%T", exp)
+			}
+			if literal.Value != expected {
+				t.Errorf("literal.Value not %g. got=%g", expected, literal.Value)
+			}
+		case string:
+			literal, ok := exp.(*ast.BigIntLiteral)
+			if !ok {
+				t.Fatalf("exp not *ast.BigIntLiteral. got=%T", exp)
+			}
+			if literal.Value.String() != expected {
+				t.Errorf("literal.Value not %s. got=%s", expected, literal.Value)
+			}
+		case ast.DecimalLiteral:
+			literal, ok := exp.(*ast.DecimalLiteral)
+			if !ok {
+				t.Fatalf("exp not *ast.DecimalLiteral. got=%T", exp)
+			}
+			if literal.Value != expected.Value {
+				t.Errorf("literal.Value not %q. got=%q", expected.Value, literal.Value)
+			}
+		}
+	}
+}
+
+func TestMalformedNumberLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0b102", `Parser Error on line 1, col 1: malformed integer literal "0b102"`},
+		{"0x", `Parser Error on line 1, col 1: malformed integer literal "0x"`},
+		{"1__0", `Parser Error on line 1, col 1: malformed integer literal "1__0"`},
+		{"12abc", `Parser Error on line 1, col 1: malformed integer literal "12abc"`},
+		{"1e", `Parser Error on line 1, col 1: malformed float literal "1e"`},
+		{"1.5n", `Parser Error on line 1, col 1: malformed integer literal "1.5n"`},
+		{"12abd", `Parser Error on line 1, col 1: malformed decimal literal "12abd"`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestLoopParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (x < 10) { x }", "while(x < 10) x"},
+		{"for (x in arr) { puts(x); break; }", "for (x in arr) puts(x)break;"},
+		{"for (k, v in m) { continue }", "for (k, v in m) continue;"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "Parser Error on line 1, col 1: break outside of a loop"},
+		{"if (true) { continue }", "Parser Error on line 1, col 13: continue outside of a loop"},
+		{"while (true) { func() { break } }", "Parser Error on line 1, col 25: break outside of a loop"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected parser errors for %q, got none", tt.input)
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "x = 5"},
+		{"x = y = 1 + 2", "x = y = (1 + 2)"},
+		{"x += a * b", "x += (a * b)"},
+		{"arr[i + 1] -= 2", "(arr[(i + 1)]) -= 2"},
+		{"m[\"k\"] /= 2 || b", "(m[\"k\"]) /= (2 || b)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestInvalidAssignTarget(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 = 3", "Parser Error on line 1, col 1: cannot assign to (1 + 2)"},
+		{"a?[1] = 3", "Parser Error on line 1, col 1: cannot assign to (a?[1])"},
+		{"a?.b = 3", "Parser Error on line 1, col 1: cannot assign to (a?.b)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors. expected=%q, got=%q", tt.expected, errors)
+		}
+	}
+}
+
+func TestOptionalChainParsing(t *testing.T) {
+	l := lexer.New("m?.name")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+	dot, ok := exp.(*ast.DotExpression)
+	if !ok {
+		t.Fatalf("exp not *ast.DotExpression. got=%T", exp)
+	}
+	if !dot.Optional {
+		t.Errorf("dot.Optional is false")
+	}
+	if !testIdentifier(t, dot.Left, "m") || !testIdentifier(t, dot.Field, "name") {
+		return
+	}
+	if dot.End().Column != 8 {
+		t.Errorf("dot.End() wrong. got=%s", dot.End())
+	}
+
+	l = lexer.New("m?.1")
+	p = New(l)
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected an error for a number after ?.")
+	}
+}
+
+func TestSetLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"{1}", []string{"1"}},
+		{"{1, \"a\", x + 1,}", []string{"1", `"a"`, "(x + 1)"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+		set, ok := exp.(*ast.SetLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.SetLiteral. got=%T", exp)
+		}
+		if len(set.Elements) != len(tt.expected) {
+			t.Fatalf("wrong number of elements. got=%d", len(set.Elements))
+		}
+		for i, el := range set.Elements {
+			if el.String() != tt.expected[i] {
+				t.Errorf("element %d wrong. want=%s, got=%s", i, tt.expected[i], el.String())
+			}
+		}
+	}
+
+	for _, input := range []string{"{1, 2: 3}", "{1 2}", "a not b"} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("%q: expected a parse error", input)
+		}
+	}
+}
+
+func TestTupleLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"()", "()"},
+		{"(1,)", "(1,)"},
+		{"(1, \"a\")", `(1, "a")`},
+		{"(1, x + 2,)", "(1, (x + 2))"},
+		{"((1, 2), 3)", "((1, 2), 3)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+		tuple, ok := exp.(*ast.TupleLiteral)
+		if !ok {
+			t.Fatalf("%q: exp not *ast.TupleLiteral. got=%T", tt.input, exp)
+		}
+		if tuple.String() != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.input, tt.expected, tuple.String())
+		}
+	}
+
+	l := lexer.New("(1)")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+	if _, ok := exp.(*ast.IntegerLiteral); !ok {
+		t.Errorf("(1) is not a grouped expression. got=%T", exp)
+	}
+}
+
+func TestStructStatement(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedName   string
+		expectedFields []string
+	}{
+		{"struct Point { x, y }", "Point", []string{"x", "y"}},
+		{"struct Point { x, y, };", "Point", []string{"x", "y"}},
+		{"struct Empty {}", "Empty", nil},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("%q: expected 1 statement. got=%d", tt.input, len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.StructStatement)
+		if !ok {
+			t.Fatalf("%q: stmt not *ast.StructStatement. got=%T", tt.input, program.Statements[0])
+		}
+		if stmt.Name.Value != tt.expectedName {
+			t.Errorf("wrong name. want=%s, got=%s", tt.expectedName, stmt.Name.Value)
+		}
+		if len(stmt.Fields) != len(tt.expectedFields) {
+			t.Fatalf("wrong number of fields. want=%d, got=%d",
+				len(tt.expectedFields), len(stmt.Fields))
+		}
+		for i, field := range stmt.Fields {
+			testIdentifier(t, field, tt.expectedFields[i])
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct P { x, x }", "Parser Error on line 1, col 15: duplicate field x in struct P"},
+		{"struct P { 1 }", "Parser Error on line 1, col 12: expected next token to be IDENT, got INT instead"},
+		{"struct { x }", "Parser Error on line 1, col 8: expected next token to be IDENT, got { instead"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors. expected=%q, got=%q", tt.expected, errors)
+		}
+	}
+}
+
+func TestImplStatement(t *testing.T) {
+	input := `impl Point {
+	def norm = func(self) { self.x * self.x + self.y * self.y };
+	def scale = func(self, k) { Point(self.x * k, self.y * k) };
+}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("expected 1 statement. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ImplStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.ImplStatement. got=%T", program.Statements[0])
+	}
+	testIdentifier(t, stmt.Name, "Point")
+	if len(stmt.Methods) != 2 {
+		t.Fatalf("wrong number of methods. got=%d", len(stmt.Methods))
+	}
+	testIdentifier(t, stmt.Methods[0].Name, "norm")
+	testIdentifier(t, stmt.Methods[1].Name, "scale")
+
+	l = lexer.New("p.scale(2).norm()")
+	p = New(l)
+	program = p.ParseProgram()
+	checkParseErrors(t, p)
+	if got := program.String(); got != "((p.scale)(2).norm)()" {
+		t.Errorf("wrong program. got=%s", got)
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"impl P { puts(1) }", "Parser Error on line 1, col 10: expected a def statement in impl block, got IDENT"},
+		{"impl P { def x = 1; ", "Parser Error on line 1, col 21: expected a def statement in impl block, got EOF"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors. expected=%q, got=%q", tt.expected, errors)
+		}
+	}
+}
+
+func TestEnumStatement(t *testing.T) {
+	input := `enum Shape { Circle(r), Rect(w, h), Empty }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParseErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("expected 1 statement. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.EnumStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.EnumStatement. got=%T", program.Statements[0])
+	}
+	testIdentifier(t, stmt.Name, "Shape")
+	if len(stmt.Variants) != 3 {
+		t.Fatalf("wrong number of variants. got=%d", len(stmt.Variants))
+	}
+	if got := stmt.String(); got != input {
+		t.Errorf("wrong String(). got=%s", got)
+	}
+	if stmt.Variants[2].Fields != nil {
+		t.Errorf("Empty has fields. got=%v", stmt.Variants[2].Fields)
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum E { A, A }", "Parser Error on line 1, col 13: duplicate variant A in enum E"},
+		{"enum E { A() }", "Parser Error on line 1, col 10: variant A has no fields, leave out the parentheses"},
+		{"enum E { A B }", "Parser Error on line 1, col 12: expected next token to be ,, got IDENT instead"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors. expected=%q, got=%q", tt.expected, errors)
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match (x) { 1 => a, _ => b }`, "match x { 1 => a, _ => b }"},
+		{`match (x) { -1..10 if x > 2 => { a } }`, "match x { (-1)..10 if (x > 2) => a }"},
+		{`match (x) { [a, ..rest] => a, [] => 0, [..] => 1 }`, "match x { [a, ..rest] => a, [] => 0, [..] => 1 }"},
+		{`match (x) { (a, b) => a, (a,) => a, (a) => a }`, "match x { (a, b) => a, (a,) => a, a => a }"},
+		{`match (x) { {"k": v, 1: _} => v }`, `match x { {"k": v, 1: _} => v }`},
+		{`match (s) { Shape.Circle(r) => r, Shape.Empty => 0, Point(x, _) => x }`,
+			"match s { (Shape.Circle)(r) => r, (Shape.Empty) => 0, Point(x, _) => x }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParseErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+		if _, ok := stmt.Expression.(*ast.MatchExpression); !ok {
+			t.Fatalf("exp not *ast.MatchExpression. got=%T", stmt.Expression)
+		}
+		if got := program.String(); got != tt.expected {
+			t.Errorf("wrong program. expected=%s, got=%s", tt.expected, got)
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { 1 a }", "Parser Error on line 1, col 15: expected next token to be =>, got IDENT instead"},
+		{"match (x) { x + 1 => 1 }", "Parser Error on line 1, col 15: expected next token to be =>, got + instead"},
+		{"match (x) { - a => 1 }", "Parser Error on line 1, col 15: expected a number after - in pattern, got IDENT"},
+		{"match (x) { [..t, a] => 1 }", "Parser Error on line 1, col 19: expected next token to be ], got IDENT instead"},
+		{"match (x) { {k: 1} => 1 }", "Parser Error on line 1, col 14: expected a literal, got IDENT"},
+		{"match (x) { * => 1 }", "Parser Error on line 1, col 13: unexpected * in pattern"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0] != tt.expected {
+			t.Errorf("wrong errors. expected=%q, got=%q", tt.expected, errors)
+		}
+	}
+}
@@ -1,11 +1,11 @@
 package repl
 
 import (
-	"bufio"
 	"io"
 	"os"
 	"strings"
 
+	"github.com/batt0s/rizzy/ast"
 	"github.com/batt0s/rizzy/evaluator"
 	"github.com/batt0s/rizzy/lexer"
 	"github.com/batt0s/rizzy/object"
@@ -33,10 +33,11 @@ func Start(in io.Reader, out io.Writer) {
 
 	var lines []string
 	var openBrackets int
+	var openRawString bool
 
 	for {
 		var line string
-		if openBrackets > 0 {
+		if openBrackets > 0 || openRawString {
 			rl.SetPrompt("...> ")
 		} else {
 			rl.SetPrompt(PROMPT)
@@ -49,6 +50,7 @@ func Start(in io.Reader, out io.Writer) {
 			} else {
 				lines = nil
 				openBrackets = 0
+				openRawString = false
 				continue
 			}
 		} else if err == io.EOF {
@@ -56,22 +58,26 @@ func Start(in io.Reader, out io.Writer) {
 		}
 
 		line = strings.TrimSpace(input)
-		if line == "" && openBrackets == 0 {
+		if line == "" && openBrackets == 0 && !openRawString {
 			continue
 		}
 
 		lines = append(lines, line)
 		openBrackets += strings.Count(line, "{")
 		openBrackets -= strings.Count(line, "}")
+		if strings.Count(line, "`")%2 == 1 {
+			openRawString = !openRawString
+		}
 
-		if openBrackets > 0 {
+		if openBrackets > 0 || openRawString {
 			continue
 		}
 
-		fullInput := strings.Join(lines, " ")
+		fullInput := strings.Join(lines, "\n")
 
 		lines = nil
 		openBrackets = 0
+		openRawString = false
 
 		l := lexer.New(fullInput)
 		p := parser.New(l)
@@ -82,7 +88,11 @@ func Start(in io.Reader, out io.Writer) {
 			continue
 		}
 
-		evaluated := evaluator.Eval(program, env)
+		evaluated := eval(program, env)
+		if err, ok := evaluated.(*object.Error); ok {
+			printTraceback(out, err, fullInput, "")
+			continue
+		}
 		if evaluated != nil {
 			io.WriteString(out, "Rizzler: ")
 			io.WriteString(out, evaluated.Inspect())
@@ -101,6 +111,18 @@ var keywords = []string{
 	"if",
 	"else",
 	"return",
+	"while",
+	"for",
+	"in",
+	"break",
+	"continue",
+	"null",
+	"is",
+	"not",
+	"struct",
+	"impl",
+	"enum",
+	"match",
 	// Basics
 	"type",
 	"puts",
@@ -116,12 +138,36 @@ var keywords = []string{
 	"push",
 	"pop",
 	"range",
+	"sort",
+	// String Operations
+	"upper",
+	"lower",
+	"trim",
+	"split",
+	"join",
+	// Map Operations
+	"keys",
+	"values",
+	"items",
+	"has",
+	"delete",
+	"merge",
+	// Set Operations
+	"set",
+	"add",
+	// Tuples and Freezing
+	"tuple",
+	"freeze",
 	// Math
 	"pow",
 	"sqrt",
 	// Types
 	"int",
 	"float",
+	"decimal",
+	// Decimal context
+	"setprecision",
+	"setrounding",
 }
 
 func (c completer) Do(line []rune, pos int) ([][]rune, int) {
@@ -145,34 +191,27 @@ func (c completer) Do(line []rune, pos int) ([][]rune, int) {
 }
 
 func RunFile(filepath string, out io.Writer) error {
-	file, err := os.Open(filepath)
+	content, err := os.ReadFile(filepath)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	var builder strings.Builder
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		builder.WriteString(scanner.Text() + " ")
-	}
-
-	if err := scanner.Err(); err != nil {
-		return err
-	}
 
-	input := builder.String()
+	input := string(content)
 
 	l := lexer.New(input)
 	p := parser.New(l)
 
 	program := p.ParseProgram()
 	if len(p.Errors()) != 0 {
-		printParserErrors(out, p.Errors())
+		printParserErrors(out, p.FileErrors(filepath))
 		return nil
 	}
 
-	evaluated := evaluator.Eval(program, object.NewEnvironment())
+	evaluated := eval(program, object.NewEnvironment())
+	if err, ok := evaluated.(*object.Error); ok {
+		printTraceback(out, err, input, filepath)
+		return nil
+	}
 	if evaluated != nil {
 		io.WriteString(out, evaluated.Inspect()+"\n")
 	}
@@ -180,6 +219,18 @@ func RunFile(filepath string, out io.Writer) error {
 	return nil
 }
 
+// eval evaluates a program, and reports a Go panic in the evaluator as an
+// error instead of crashing.
+func eval(program *ast.Program, env *object.Environment) (evaluated object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			evaluated = evaluator.Recovered(r)
+		}
+	}()
+
+	return evaluator.Eval(program, env)
+}
+
 func printParserErrors(out io.Writer, errors []string) {
 	for _, msg := range errors {
 		io.WriteString(out, msg+"\n")
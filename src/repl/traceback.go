@@ -0,0 +1,88 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/batt0s/rizzy/object"
+	"github.com/batt0s/rizzy/token"
+)
+
+// printTraceback writes a runtime error together with the source line it
+// happened on and the function calls it went through, innermost first.
+func printTraceback(out io.Writer, err *object.Error, source, filename string) {
+	io.WriteString(out, err.Inspect()+"\n")
+
+	if !err.Pos.IsValid() {
+		return
+	}
+
+	lines := strings.Split(source, "\n")
+
+	fmt.Fprintf(out, "  at %s\n", location(filename, err.Pos))
+	printSourceLine(out, lines, err.Pos, err.End)
+
+	for i := 0; i < len(err.Stack); {
+		frame := err.Stack[i]
+
+		// Collapse deep recursion into a single entry.
+		repeated := 1
+		for i+repeated < len(err.Stack) && err.Stack[i+repeated] == frame {
+			repeated++
+		}
+
+		name := frame.Function
+		if name == "" {
+			name = "<anonymous>"
+		}
+		fmt.Fprintf(out, "  in %s, called at %s\n", name, location(filename, frame.Pos))
+		if repeated > 1 {
+			fmt.Fprintf(out, "  [previous call repeated %d more times]\n", repeated-1)
+		}
+
+		i += repeated
+	}
+}
+
+func location(filename string, pos token.Position) string {
+	if filename == "" {
+		return pos.String()
+	}
+	return filename + ":" + pos.String()
+}
+
+// printSourceLine prints the line pos is on and underlines the span up to
+// end, or up to the end of the line if the span continues on other lines.
+func printSourceLine(out io.Writer, lines []string, pos, end token.Position) {
+	if pos.Line > len(lines) {
+		return
+	}
+	line := []rune(strings.TrimRight(lines[pos.Line-1], "\r"))
+
+	// Columns count characters, so index the line by runes.
+	start := pos.Column - 1
+	if start > len(line) {
+		start = len(line)
+	}
+	stop := len(line)
+	if end.Line == pos.Line && end.Column-1 < stop {
+		stop = end.Column - 1
+	}
+	if stop <= start {
+		stop = start + 1
+	}
+
+	// Keep tabs so the marker lines up with the source line.
+	var padding strings.Builder
+	for _, ch := range line[:start] {
+		if ch == '\t' {
+			padding.WriteRune('\t')
+		} else {
+			padding.WriteRune(' ')
+		}
+	}
+
+	io.WriteString(out, "    "+string(line)+"\n")
+	io.WriteString(out, "    "+padding.String()+"^"+strings.Repeat("~", stop-start-1)+"\n")
+}
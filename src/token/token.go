@@ -1,44 +1,92 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
+// Position is a location in the source text. Line and Column start at 1,
+// Offset is the byte offset from the start of the input.
+type Position struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// IsValid reports whether the position was set by the lexer.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
 type Token struct {
 	Type    TokenType
 	Literal string
+
+	// Pos is the position of the first character of the token,
+	// End the position right after its last character.
+	Pos Position
+	End Position
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT"
 
 	// Identifiers + Literals
-	IDENT  = "IDENT"
-	INT    = "INT"
-	FLOAT  = "FLOAT"
-	STRING = "STRING"
+	IDENT   = "IDENT"
+	INT     = "INT"
+	BIGINT  = "BIGINT"
+	FLOAT   = "FLOAT"
+	DECIMAL = "DECIMAL"
+	STRING  = "STRING"
+
+	// Pieces of an interpolated string such as "a ${x} b ${y} c": the
+	// text up to the first "${", the text between embedded expressions,
+	// and the text after the last one up to the closing quote.
+	INTERP_START = "INTERP_START"
+	INTERP_MID   = "INTERP_MID"
+	INTERP_END   = "INTERP_END"
 
 	// Operators
-	ASSIGN   = "="
-	PLUS     = "+"
-	MINUS    = "-"
-	BANG     = "!"
-	ASTERISK = "*"
-	SLASH    = "/"
-	LT       = "<"
-	LTE      = "<="
-	GT       = ">"
-	GTE      = ">="
-	EQ       = "=="
-	NOT_EQ   = "!="
-	BIT_AND  = "&"
-	BIT_OR   = "|"
-	AND      = "&&"
-	OR       = "||"
+	ASSIGN          = "="
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+	PLUS            = "+"
+	MINUS           = "-"
+	BANG            = "!"
+	ASTERISK        = "*"
+	SLASH           = "/"
+	PERCENT         = "%"
+	POWER           = "**"
+	LT              = "<"
+	LTE             = "<="
+	GT              = ">"
+	GTE             = ">="
+	EQ              = "=="
+	NOT_EQ          = "!="
+	BIT_AND         = "&"
+	BIT_OR          = "|"
+	BIT_XOR         = "^"
+	SHL             = "<<"
+	SHR             = ">>"
+	AND             = "&&"
+	OR              = "||"
+	NULLISH         = "??"
+	QUESTION        = "?"
 
 	// Delimeters
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	DOT       = "."
+	DOTDOT    = ".."
+	ARROW     = "=>"
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -47,6 +95,10 @@ const (
 	LBRACKET = "["
 	RBRACKET = "]"
 
+	// Optional chaining, a?.b and a?[i]
+	OPT_DOT      = "?."
+	OPT_LBRACKET = "?["
+
 	// Keywords
 	FUNCTION = "FUNCTION"
 	DEF      = "DEF"
@@ -55,16 +107,40 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	NULL     = "NULL"
+	IS       = "IS"
+	NOT      = "NOT"
+	STRUCT   = "STRUCT"
+	IMPL     = "IMPL"
+	ENUM     = "ENUM"
+	MATCH    = "MATCH"
 )
 
 var keywords = map[string]TokenType{
-	"func":   FUNCTION,
-	"def":    DEF,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"func":     FUNCTION,
+	"def":      DEF,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"null":     NULL,
+	"is":       IS,
+	"not":      NOT,
+	"struct":   STRUCT,
+	"impl":     IMPL,
+	"enum":     ENUM,
+	"match":    MATCH,
 }
 
 func LookupIdent(ident string) TokenType {